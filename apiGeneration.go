@@ -13,10 +13,20 @@ import (
 
 type TemplateTableData struct {
 	TableName   string
+	Label       string
+	Description string
 	PrimaryKey  string
 	Columns     []Column
+	Lineage     []Column // lineage columns, selected through ReadConfig only
 	IsAuthTable bool
 	TableConfig TableConfig
+	Checks      []TemplateCheck
+}
+
+type TemplateCheck struct {
+	Name       string
+	Expression string
+	Code       string // go expression evaluated against the request body
 }
 
 type TemplateFnCall struct {
@@ -78,6 +88,9 @@ func (dbSchema *DB) getTemplatesMetaData(basePath, appPath string, appConfig *Ap
 				"sliceContains":            slices.Contains[[]string, string],
 				"getColumnDataType":        getDataTypeFn(slicedTableData),
 				"getProtectedValuesByRole": getProtectedValuesByRole,
+				"getInsertColumns":         getInsertColumns,
+				"getGeneratedColumns":      getGeneratedColumns,
+				"getArrayScanner":          getArrayScanner,
 			},
 		},
 
@@ -86,11 +99,12 @@ func (dbSchema *DB) getTemplatesMetaData(basePath, appPath string, appConfig *Ap
 			filePath:     filepath.Join(appPath, "models.go"),
 			templatePath: filepath.Join(basePath, "model.tmpl"),
 			templateFuncs: template.FuncMap{
-				"getDbType":         getDbType,
-				"getOrgFields":      getOrgFields,
-				"capitalize":        capitalize,
-				"sliceContains":     slices.Contains[[]string, string],
-				"getColumnDataType": getDataTypeFn(slicedTableData),
+				"getDbType":           getDbType,
+				"getOrgFields":        getOrgFields,
+				"capitalize":          capitalize,
+				"sliceContains":       slices.Contains[[]string, string],
+				"getColumnDataType":   getDataTypeFn(slicedTableData),
+				"templateAppPatterns": templateAppPatterns,
 			},
 			data: slicedTableData,
 		},
@@ -100,15 +114,22 @@ func (dbSchema *DB) getTemplatesMetaData(basePath, appPath string, appConfig *Ap
 			filePath:     filepath.Join(appPath, "httpUtils.go"),
 			templatePath: filepath.Join(basePath, "http.tmpl"),
 			templateFuncs: template.FuncMap{
-				"getPkType":          getPkType,
-				"HasSuffix":          strings.HasSuffix,
-				"TrimPrefix":         strings.TrimPrefix,
-				"increase":           increase,
-				"decrease":           decrease,
-				"capitalize":         capitalize,
-				"getOrgFields":       getOrgFields,
-				"getDbType":          getDbType,
-				"templateProtectMap": templateProtectMap,
+				"getPkType":           getPkType,
+				"HasSuffix":           strings.HasSuffix,
+				"elementType":         elementType,
+				"increase":            increase,
+				"decrease":            decrease,
+				"capitalize":          capitalize,
+				"getOrgFields":        getOrgFields,
+				"getDbType":           getDbType,
+				"templateProtectMap":  templateProtectMap,
+				"templateBoundsArgs":  templateBoundsArgs,
+				"templateBoundValue":  templateBoundValue,
+				"templateFlattenArr":  templateFlattenArr,
+				"templateAppPatterns": templateAppPatterns,
+				"getGeneratedColumns": getGeneratedColumns,
+				"displayLabel":        displayLabel,
+				"sliceContains":       slices.Contains[[]string, string],
 			},
 			data: slicedTableData,
 		},
@@ -188,20 +209,22 @@ func executeTemplate(filePath, templatePath string, templateData any, templateFu
 }
 
 func getDbType(datatype string) string {
-	res := ""
-
-	if strings.HasSuffix(datatype, "[]") {
-		res += "[]"
-		datatype = strings.TrimSuffix(datatype, "[]")
-	}
+	res := strings.Repeat("[]", arrayDims(datatype))
+	datatype = elementType(datatype)
 
-	switch datatype {
-	case "integer":
+	switch baseType(datatype) {
+	case "smallint", "integer", "bigint":
 		res += "CustomNullInt"
 	case "real":
 		res += "CustomNullFloat"
+	case "numeric":
+		res += "CustomNullNumeric"
 	case "text":
 		res += "CustomNullString"
+	case "uuid":
+		res += "CustomNullUUID"
+	case "jsonb":
+		res += "CustomNullJSON"
 	case "boolean":
 		res += "CustomNullBool"
 	case "date":
@@ -215,6 +238,95 @@ func getDbType(datatype string) string {
 	return res
 }
 
+// returns the min, max, minExclusive, maxExclusive & compare arguments of checkBounds in generated app
+// empty string indicates no individual bounds
+func templateBoundsArgs(column Column) string {
+	if column.minIndividual == nil && column.maxIndividual == nil {
+		return ""
+	}
+
+	datatype := elementType(column.DataType)
+
+	formatBound := func(bound any) string {
+		if bound == nil {
+			return "nil"
+		}
+
+		switch baseType(datatype) {
+		case "smallint", "integer", "bigint", "text":
+			return fmt.Sprintf("ptr(int64(%v))", bound)
+		case "real":
+			return fmt.Sprintf("ptr(float64(%v))", bound)
+		case "numeric":
+			return fmt.Sprintf("ptr(%q)", bound)
+		case "date", "time", "timestamptz":
+			parsed, _ := bound.(time.Time)
+			return fmt.Sprintf("ptr(parseTimeBound(%#v, %#v))", datetimeFormats[datatype], parsed.Format(datetimeFormats[datatype]))
+		}
+
+		return "nil"
+	}
+
+	compare := "cmp.Compare[int64]"
+	switch baseType(datatype) {
+	case "real":
+		compare = "cmp.Compare[float64]"
+	case "numeric":
+		compare = "compareNumeric"
+	case "date", "time", "timestamptz":
+		compare = "time.Time.Compare"
+	}
+
+	return fmt.Sprintf("%s, %s, %v, %v, %s", formatBound(column.minIndividual), formatBound(column.maxIndividual), column.MinExclusive, column.MaxExclusive, compare)
+}
+
+// returns the value compared by checkBounds in generated app, text is bounded by its length
+func templateBoundValue(column Column, variable string) string {
+	if elementType(column.DataType) == "text" {
+		return fmt.Sprintf("int64(utf8.RuneCountInString(%s.GetValue()))", variable)
+	}
+
+	return variable + ".GetValue()"
+}
+
+// returns the wrapper scanning array columns in generated app, lib/pq scans one-dimensional arrays only
+func getArrayScanner(datatype string) string {
+	if arrayDims(datatype) > 1 {
+		return "scanMultiArray"
+	}
+	return "pq.Array"
+}
+
+// returns the expression flattening a multi-dimensional array variable into its elements
+func templateFlattenArr(datatype, variable string) string {
+	for dims := arrayDims(datatype); dims > 1; dims-- {
+		variable = fmt.Sprintf("flattenArr(%s)", variable)
+	}
+	return variable
+}
+
+// columns set by the app during inserts
+func getInsertColumns(columns []Column) []Column {
+	res := []Column{}
+	for _, column := range columns {
+		if !column.IsGenerated() {
+			res = append(res, column)
+		}
+	}
+	return res
+}
+
+// columns generated by database, returned after inserts
+func getGeneratedColumns(columns []Column) []Column {
+	res := []Column{}
+	for _, column := range columns {
+		if column.IsGenerated() {
+			res = append(res, column)
+		}
+	}
+	return res
+}
+
 func getPkType(table TemplateTableData) string {
 	if table.PrimaryKey == "" {
 		return "CustomNullInt"
@@ -236,12 +348,19 @@ func (dbSchema *DB) getSlicedTableData(appConfig *AppCongif) []TemplateTableData
 	for _, table := range dbSchema.Tables {
 		item := TemplateTableData{
 			TableName:   table.TableName,
+			Label:       table.Label,
+			Description: table.Description,
 			PrimaryKey:  table.PrimaryKey,
 			Columns:     []Column{},
+			Lineage:     table.LineageColumns(),
 			IsAuthTable: table.TableName == appConfig.AuthTable,
 			TableConfig: appConfig.Tables[table.TableName],
 		}
 
+		for _, check := range table.checks {
+			item.Checks = append(item.Checks, TemplateCheck{Name: check.name, Expression: check.expression, Code: check.root.toGo()})
+		}
+
 		for _, column := range table.Columns {
 			item.Columns = append(item.Columns, column)
 		}
@@ -386,6 +505,12 @@ func (appConfig *AppCongif) validateAppConfig(dbSchema *DB) error {
 			return fmt.Errorf(`invalid deleteAuth for %s table: %w`, tableName, err)
 		}
 
+		for _, column := range table.Columns {
+			if column.DefaultExpr == "jwt_user" && authTable.TableName == "" {
+				return fmt.Errorf(`jwt_user default of "%s" column in "%s" table requires an auth table`, column.ColumnName, tableName)
+			}
+		}
+
 		if tableConfig.DefaultPagination == 0 {
 			return fmt.Errorf(`invalid default pagination for "%s" table`, tableName)
 		}
@@ -395,8 +520,14 @@ func (appConfig *AppCongif) validateAppConfig(dbSchema *DB) error {
 }
 
 func (readConfig *ReadConfig) validateReadConfig(dbSchema *DB, tableName string) error {
+	table := dbSchema.Tables[tableName]
+
 	for _, field := range readConfig.Columns {
-		column, exists := dbSchema.Tables[tableName].Columns[field]
+		if table.isLineageColumn(field) {
+			continue
+		}
+
+		column, exists := table.Columns[field]
 
 		if !exists {
 			return fmt.Errorf(`"%s" field not found in table columns`, field)
@@ -536,16 +667,16 @@ func (authInfo *AuthInfo) validateAuthInfo(rolesEnum, appOrgFields []string, col
 }
 
 func templateProtectMap(m map[string][]string, datatype string) string {
-	datatype = strings.TrimSuffix(datatype, "[]")
+	datatype = elementType(datatype)
 
-	switch datatype {
-	case "integer":
+	switch baseType(datatype) {
+	case "smallint", "integer", "bigint":
 		res, _ := assertProtectedMap[int64](m, datatype)
 		return fmt.Sprintf("%#v", res)
 	case "real":
 		res, _ := assertProtectedMap[float64](m, datatype)
 		return fmt.Sprintf("%#v", res)
-	case "text":
+	case "text", "numeric", "uuid":
 		res, _ := assertProtectedMap[string](m, datatype)
 		return fmt.Sprintf("%#v", res)
 	case "boolean":
@@ -566,10 +697,10 @@ func templateProtectMap(m map[string][]string, datatype string) string {
 }
 
 func validateProtectMap(m map[string][]string, datatype string, enums []any) error {
-	datatype = strings.TrimSuffix(datatype, "[]")
+	datatype = elementType(datatype)
 
-	switch datatype {
-	case "integer":
+	switch baseType(datatype) {
+	case "smallint", "integer", "bigint":
 		castedMap, err := assertProtectedMap[int64](m, datatype)
 		if err != nil {
 			return err
@@ -593,7 +724,7 @@ func validateProtectMap(m map[string][]string, datatype string, enums []any) err
 				return fmt.Errorf(`%v value not found in column enums`, castedValue)
 			}
 		}
-	case "text":
+	case "text", "numeric", "uuid":
 		castedMap, err := assertProtectedMap[string](m, datatype)
 		if err != nil {
 			return err
@@ -668,9 +799,9 @@ func assertProtectedMap[T comparable](m map[string][]string, datatype string) (m
 	res := make(map[T][]string, len(m))
 
 	for strVal, roles := range m {
-		interfaceVal, err := typeConversionFuncs[datatype](strVal)
+		interfaceVal, ok := validateValueByType(strVal, datatype)
 
-		if err != nil {
+		if !ok {
 			return nil, fmt.Errorf(`failed to type cast value %s`, strVal)
 		}
 
@@ -699,10 +830,10 @@ func getProtectedValuesByRole(m map[string][]string, datatype string) map[string
 		}
 	}
 
-	datatype = strings.TrimPrefix(datatype, "[]")
+	datatype = elementType(datatype)
 
 	for value, allowedRoles := range m {
-		interfaceVal, _ := typeConversionFuncs[datatype](value)
+		interfaceVal, _ := validateValueByType(value, datatype)
 
 		for _, role := range roles {
 			if !slices.Contains(allowedRoles, role) {
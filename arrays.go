@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// max dimensions of Postgres arrays
+const maxArrayDims = 6
+
+// returns the number of dimensions of array types, 0 for non-array types
+func arrayDims(datatype string) int {
+	dims := 0
+	for strings.HasSuffix(datatype, "[]") {
+		datatype = strings.TrimSuffix(datatype, "[]")
+		dims++
+	}
+	return dims
+}
+
+// returns the datatype of array elements, e.g. integer[][] -> integer
+func elementType(datatype string) string {
+	for strings.HasSuffix(datatype, "[]") {
+		datatype = strings.TrimSuffix(datatype, "[]")
+	}
+	return datatype
+}
+
+// returns the lengths of each dimension of a rectangular array
+// false for ragged arrays, objects & empty sub-arrays, which Postgres arrays can't hold
+func arrayShape(arr []any) ([]int, bool) {
+	var inner []int
+
+	for idx, item := range arr {
+		var itemShape []int
+
+		switch item := item.(type) {
+		case map[string]any:
+			return nil, false
+		case []any:
+			if len(item) == 0 {
+				return nil, false
+			}
+
+			var ok bool
+			if itemShape, ok = arrayShape(item); !ok {
+				return nil, false
+			}
+		}
+
+		if idx > 0 && !slices.Equal(inner, itemShape) {
+			return nil, false
+		}
+		inner = itemShape
+	}
+
+	return append([]int{len(arr)}, inner...), true
+}
+
+// returns the elements of nested arrays in row-major order
+func flattenArray(arr []any) []any {
+	res := []any{}
+	for _, item := range arr {
+		if subArr, ok := item.([]any); ok {
+			res = append(res, flattenArray(subArr)...)
+		} else {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// parses JSON arrays (string or []any) & validates their dimensions against datatype, empty arrays are allowed
+func parseArray(value any, datatype string) ([]any, error) {
+	if text, ok := value.(string); ok {
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, err
+		}
+	}
+
+	arr, ok := value.([]any)
+	if !ok {
+		return nil, errors.New("failed to convert to array")
+	}
+
+	if len(arr) == 0 {
+		return arr, nil
+	}
+
+	shape, ok := arrayShape(arr)
+	if !ok {
+		return nil, errors.New("array should be rectangular without objects & empty sub-arrays")
+	}
+
+	if dims := arrayDims(datatype); len(shape) != dims {
+		return nil, fmt.Errorf("need %d dimensional array, found %d dimensions", dims, len(shape))
+	}
+
+	return arr, nil
+}
+
+// replaces the elements of nested arrays by the results of parse
+func mapArray(arr []any, parse func(any) (any, error)) error {
+	for idx, item := range arr {
+		if subArr, ok := item.([]any); ok {
+			if err := mapArray(subArr, parse); err != nil {
+				return err
+			}
+			continue
+		}
+
+		parsed, err := parse(item)
+		if err != nil {
+			return err
+		}
+		arr[idx] = parsed
+	}
+	return nil
+}
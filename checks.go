@@ -0,0 +1,763 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/*
+Table level check expressions over column names, e.g.
+
+	end_date >= start_date
+	Lateral_Allowed IMPLIES course_id IS NOT NULL
+
+Grammar (lowest precedence first):
+
+	implies    := or [IMPLIES implies]
+	or         := and {OR and}
+	and        := not {AND not}
+	not        := NOT not | comparison
+	comparison := "(" implies ")" | operand [operator operand | IS [NOT] NULL]
+	operand    := column | "quoted column" | number | 'text' | TRUE | FALSE
+	operator   := = | != | <> | < | <= | > | >=
+
+Like SQL, a check is violated only if it evaluates to false, NULL operands make it unknown.
+*/
+
+var checkKeywords = []string{"AND", "OR", "NOT", "IMPLIES", "IS", "NULL", "TRUE", "FALSE"}
+
+var checkOperators = []string{"=", "!=", "<>", "<", "<=", ">", ">="}
+
+type checkToken struct {
+	kind  string // identifier, keyword, number, text, operator, arithmetic, (, ) or ,
+	value string
+}
+
+type checkNode struct {
+	kind     string // implies, or, and, not, compare, isNull, isNotNull, column, literal
+	operator string // comparison operator
+	children []*checkNode
+	name     string // column name
+	literal  string // literal kind: number, text or boolean
+	raw      string // literal as written
+	value    any    // typed literal, set by resolve
+	datatype string // datatype of operands & comparisons, set by resolve
+	enumType bool   // column of enum type, ordering isn't supported
+}
+
+type tableCheck struct {
+	name       string
+	expression string
+	root       *checkNode
+}
+
+// three-valued result of a check, unknown satisfies the check like SQL
+type checkResult int8
+
+const (
+	checkFalse checkResult = iota - 1
+	checkUnknown
+	checkTrue
+)
+
+func tokenizeCheck(expression string) ([]checkToken, error) {
+	tokens := []checkToken{}
+	runes := []rune(expression)
+
+	for idx := 0; idx < len(runes); {
+		char := runes[idx]
+
+		switch {
+		case unicode.IsSpace(char):
+			idx++
+
+		case char == '(' || char == ')':
+			tokens = append(tokens, checkToken{kind: string(char), value: string(char)})
+			idx++
+
+		case char == '\'' || char == '"':
+			var builder strings.Builder
+			end := idx + 1
+			for ; end < len(runes); end++ {
+				if runes[end] == char {
+					if end+1 < len(runes) && runes[end+1] == char { // escaped quote
+						builder.WriteRune(char)
+						end++
+						continue
+					}
+					break
+				}
+				builder.WriteRune(runes[end])
+			}
+
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated %c at position %d", char, idx+1)
+			}
+
+			kind := "text"
+			if char == '"' {
+				kind = "identifier"
+			}
+			tokens = append(tokens, checkToken{kind: kind, value: builder.String()})
+			idx = end + 1
+
+		case unicode.IsDigit(char) || (char == '-' && idx+1 < len(runes) && unicode.IsDigit(runes[idx+1])):
+			end := idx + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, checkToken{kind: "number", value: string(runes[idx:end])})
+			idx = end
+
+		case unicode.IsLetter(char) || char == '_':
+			end := idx + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+
+			word := string(runes[idx:end])
+			if slices.Contains(checkKeywords, strings.ToUpper(word)) {
+				tokens = append(tokens, checkToken{kind: "keyword", value: strings.ToUpper(word)})
+			} else {
+				tokens = append(tokens, checkToken{kind: "identifier", value: word})
+			}
+			idx = end
+
+		case char == ',':
+			tokens = append(tokens, checkToken{kind: ",", value: ","})
+			idx++
+
+		// arithmetic & concatenation of computed column expressions
+		case strings.ContainsRune("+-*/", char) || (char == '|' && idx+1 < len(runes) && runes[idx+1] == '|'):
+			operator := string(char)
+			if char == '|' {
+				operator = "||"
+			}
+			tokens = append(tokens, checkToken{kind: "arithmetic", value: operator})
+			idx += len(operator)
+
+		case strings.ContainsRune("=!<>", char):
+			end := idx + 1
+			if end < len(runes) && strings.ContainsRune("=>", runes[end]) {
+				end++
+			}
+
+			operator := string(runes[idx:end])
+			if !slices.Contains(checkOperators, operator) {
+				return nil, fmt.Errorf("invalid operator %s at position %d", operator, idx+1)
+			}
+			tokens = append(tokens, checkToken{kind: "operator", value: operator})
+			idx = end
+
+		default:
+			return nil, fmt.Errorf("unexpected %c at position %d", char, idx+1)
+		}
+	}
+
+	return tokens, nil
+}
+
+type checkParser struct {
+	tokens []checkToken
+	pos    int
+}
+
+func (parser *checkParser) peek() checkToken {
+	if parser.pos >= len(parser.tokens) {
+		return checkToken{}
+	}
+	return parser.tokens[parser.pos]
+}
+
+func (parser *checkParser) next() checkToken {
+	token := parser.peek()
+	parser.pos++
+	return token
+}
+
+func (parser *checkParser) isKeyword(keyword string) bool {
+	token := parser.peek()
+	return token.kind == "keyword" && token.value == keyword
+}
+
+func parseCheck(expression string) (*checkNode, error) {
+	tokens, err := tokenizeCheck(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("empty expression")
+	}
+
+	parser := &checkParser{tokens: tokens}
+	root, err := parser.parseImplies()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.pos < len(tokens) {
+		return nil, fmt.Errorf("unexpected %s", parser.peek().value)
+	}
+
+	return root, nil
+}
+
+func (parser *checkParser) parseImplies() (*checkNode, error) {
+	left, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if !parser.isKeyword("IMPLIES") {
+		return left, nil
+	}
+	parser.next()
+
+	right, err := parser.parseImplies()
+	if err != nil {
+		return nil, err
+	}
+
+	return &checkNode{kind: "implies", children: []*checkNode{left, right}}, nil
+}
+
+func (parser *checkParser) parseOr() (*checkNode, error) {
+	node, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.isKeyword("OR") {
+		parser.next()
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		node = &checkNode{kind: "or", children: []*checkNode{node, right}}
+	}
+
+	return node, nil
+}
+
+func (parser *checkParser) parseAnd() (*checkNode, error) {
+	node, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.isKeyword("AND") {
+		parser.next()
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		node = &checkNode{kind: "and", children: []*checkNode{node, right}}
+	}
+
+	return node, nil
+}
+
+func (parser *checkParser) parseNot() (*checkNode, error) {
+	if !parser.isKeyword("NOT") {
+		return parser.parseComparison()
+	}
+	parser.next()
+
+	child, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &checkNode{kind: "not", children: []*checkNode{child}}, nil
+}
+
+func (parser *checkParser) parseComparison() (*checkNode, error) {
+	if parser.peek().kind == "(" {
+		parser.next()
+
+		node, err := parser.parseImplies()
+		if err != nil {
+			return nil, err
+		}
+
+		if parser.next().kind != ")" {
+			return nil, errors.New("missing )")
+		}
+
+		return node, nil
+	}
+
+	left, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.isKeyword("IS") {
+		parser.next()
+
+		kind := "isNull"
+		if parser.isKeyword("NOT") {
+			parser.next()
+			kind = "isNotNull"
+		}
+
+		if !parser.isKeyword("NULL") {
+			return nil, errors.New("expected NULL after IS")
+		}
+		parser.next()
+
+		return &checkNode{kind: kind, children: []*checkNode{left}}, nil
+	}
+
+	if parser.peek().kind != "operator" {
+		return left, nil
+	}
+
+	operator := parser.next().value
+	if operator == "<>" {
+		operator = "!="
+	}
+
+	right, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return &checkNode{kind: "compare", operator: operator, children: []*checkNode{left, right}}, nil
+}
+
+func (parser *checkParser) parseOperand() (*checkNode, error) {
+	token := parser.next()
+
+	switch {
+	case token.kind == "identifier":
+		return &checkNode{kind: "column", name: token.value}, nil
+	case token.kind == "number" || token.kind == "text":
+		return &checkNode{kind: "literal", literal: token.kind, raw: token.value}, nil
+	case token.kind == "keyword" && (token.value == "TRUE" || token.value == "FALSE"):
+		return &checkNode{kind: "literal", literal: "boolean", raw: strings.ToLower(token.value)}, nil
+	case token.kind == "":
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %s", token.value)
+}
+
+// type checks the expression against table columns and types the literals
+func (node *checkNode) resolve(columns map[string]Column) error {
+	switch node.kind {
+	case "implies", "or", "and", "not":
+		for _, child := range node.children {
+			if err := child.resolve(columns); err != nil {
+				return err
+			}
+			if !child.isPredicate() {
+				return fmt.Errorf("%s operand should be a condition", strings.ToUpper(node.kind))
+			}
+		}
+
+	case "isNull", "isNotNull":
+		child := node.children[0]
+		if child.kind != "column" {
+			return errors.New("IS NULL requires a column")
+		}
+		return child.resolve(columns)
+
+	case "column":
+		column, ok := columns[node.name]
+		if !ok {
+			return fmt.Errorf("unknown column %s", node.name)
+		}
+
+		if strings.HasSuffix(column.DataType, "[]") || column.Hash {
+			return fmt.Errorf("array & hashed columns like %s can't be used", node.name)
+		}
+
+		if baseType(column.DataType) == "numeric" || column.DataType == "jsonb" {
+			return fmt.Errorf("%s columns like %s can't be used", baseType(column.DataType), node.name)
+		}
+
+		// integers of all widths are int64 values
+		node.datatype = column.DataType
+		if isIntegerType(column.DataType) {
+			node.datatype = "integer"
+		}
+		node.enumType = column.enumKind == "type"
+
+	case "literal":
+		if node.literal == "boolean" {
+			node.datatype = "boolean"
+			node.value = node.raw == "true"
+		}
+
+	case "compare":
+		left, right := node.children[0], node.children[1]
+		for _, child := range node.children {
+			if err := child.resolve(columns); err != nil {
+				return err
+			}
+		}
+
+		if left.kind != "column" && right.kind != "column" {
+			return errors.New("comparison should involve a column")
+		}
+
+		if (left.enumType || right.enumType) && node.operator != "=" && node.operator != "!=" {
+			return errors.New("enum type columns support only = & != comparisons")
+		}
+
+		if left.kind == "column" && right.kind == "column" {
+			if isNumericType(left.datatype) && isNumericType(right.datatype) && left.datatype != right.datatype {
+				node.datatype = "real"
+				return nil
+			}
+			if left.datatype != right.datatype {
+				return fmt.Errorf("can't compare %s of %s type with %s of %s type", left.name, left.datatype, right.name, right.datatype)
+			}
+			node.datatype = left.datatype
+			return nil
+		}
+
+		column, literal := left, right
+		if left.kind == "literal" {
+			column, literal = right, left
+		}
+
+		if err := literal.setLiteralType(column.datatype); err != nil {
+			return err
+		}
+		node.datatype = column.datatype
+	}
+
+	return nil
+}
+
+// converts the literal into the provided datatype
+func (node *checkNode) setLiteralType(datatype string) error {
+	node.datatype = datatype
+
+	if datatype == "text" && node.raw == "" {
+		node.value = ""
+		return nil
+	}
+
+	parseType := datatype
+	if datatype == "integer" {
+		parseType = "bigint"
+	}
+
+	value, ok := validateValueByType(node.raw, parseType)
+	if !ok || value == nil {
+		return fmt.Errorf("%s literal isn't of %s type", node.raw, datatype)
+	}
+	node.value = value
+
+	return nil
+}
+
+func (node *checkNode) isPredicate() bool {
+	switch node.kind {
+	case "column", "literal":
+		return node.datatype == "boolean"
+	}
+	return true
+}
+
+// adds the names of the columns referenced by the expression to names
+func (node *checkNode) columnNames(names map[string]bool) {
+	if node.kind == "column" {
+		names[node.name] = true
+	}
+	for _, child := range node.children {
+		child.columnNames(names)
+	}
+}
+
+func isNumericType(datatype string) bool {
+	return datatype == "integer" || datatype == "real"
+}
+
+// evaluates the check for a row, values are keyed by column names
+func (node *checkNode) evaluate(values map[string]any) checkResult {
+	switch node.kind {
+	case "implies":
+		return checkOr(checkNot(node.children[0].evaluate(values)), node.children[1].evaluate(values))
+	case "or":
+		return checkOr(node.children[0].evaluate(values), node.children[1].evaluate(values))
+	case "and":
+		return checkAnd(node.children[0].evaluate(values), node.children[1].evaluate(values))
+	case "not":
+		return checkNot(node.children[0].evaluate(values))
+	case "isNull":
+		return checkIf(values[node.children[0].name] == nil)
+	case "isNotNull":
+		return checkIf(values[node.children[0].name] != nil)
+	case "column", "literal":
+		value, ok := node.operandValue(values).(bool)
+		if !ok {
+			return checkUnknown
+		}
+		return checkIf(value)
+	}
+
+	// compare
+	left := node.children[0].operandValue(values)
+	right := node.children[1].operandValue(values)
+	if left == nil || right == nil {
+		return checkUnknown
+	}
+
+	res, ok := compareCheckValues(left, right, node.datatype)
+	if !ok {
+		return checkUnknown
+	}
+
+	switch node.operator {
+	case "=":
+		return checkIf(res == 0)
+	case "!=":
+		return checkIf(res != 0)
+	case "<":
+		return checkIf(res < 0)
+	case "<=":
+		return checkIf(res <= 0)
+	case ">":
+		return checkIf(res > 0)
+	}
+
+	return checkIf(res >= 0)
+}
+
+func (node *checkNode) operandValue(values map[string]any) any {
+	if node.kind == "column" {
+		return values[node.name]
+	}
+	return node.value
+}
+
+// unlike compareTypeValues, text is compared lexically & integers can be compared with reals
+func compareCheckValues(a, b any, datatype string) (int, bool) {
+	switch datatype {
+	case "real":
+		x, ok1 := toFloat(a)
+		y, ok2 := toFloat(b)
+		return cmp.Compare(x, y), ok1 && ok2
+	case "integer":
+		x, ok1 := a.(int64)
+		y, ok2 := b.(int64)
+		return cmp.Compare(x, y), ok1 && ok2
+	case "text", "uuid":
+		x, ok1 := a.(string)
+		y, ok2 := b.(string)
+		return strings.Compare(x, y), ok1 && ok2
+	case "boolean":
+		x, ok1 := a.(bool)
+		y, ok2 := b.(bool)
+		return compareBool(x, y), ok1 && ok2
+	case "date", "time", "timestamptz":
+		x, ok1 := a.(time.Time)
+		y, ok2 := b.(time.Time)
+		return x.Compare(y), ok1 && ok2
+	}
+
+	return 0, false
+}
+
+func toFloat(value any) (float64, bool) {
+	switch casted := value.(type) {
+	case int64:
+		return float64(casted), true
+	case float64:
+		return casted, true
+	}
+	return 0, false
+}
+
+// false is smaller than true
+func compareBool(a, b bool) int {
+	if a == b {
+		return 0
+	}
+	if b {
+		return -1
+	}
+	return 1
+}
+
+func checkIf(condition bool) checkResult {
+	if condition {
+		return checkTrue
+	}
+	return checkFalse
+}
+
+func checkNot(a checkResult) checkResult {
+	return -a
+}
+
+func checkAnd(a, b checkResult) checkResult {
+	return min(a, b)
+}
+
+func checkOr(a, b checkResult) checkResult {
+	return max(a, b)
+}
+
+// SQL expression of the check
+func (node *checkNode) toSQL() string {
+	switch node.kind {
+	case "implies":
+		return fmt.Sprintf("(NOT %s OR %s)", node.children[0].toSQL(), node.children[1].toSQL())
+	case "or", "and":
+		return fmt.Sprintf("(%s %s %s)", node.children[0].toSQL(), strings.ToUpper(node.kind), node.children[1].toSQL())
+	case "not":
+		return fmt.Sprintf("(NOT %s)", node.children[0].toSQL())
+	case "isNull":
+		return fmt.Sprintf("(%s IS NULL)", node.children[0].toSQL())
+	case "isNotNull":
+		return fmt.Sprintf("(%s IS NOT NULL)", node.children[0].toSQL())
+	case "column":
+		return fmt.Sprintf(`"%s"`, node.name)
+	case "literal":
+		if str, ok := node.value.(string); ok {
+			return "'" + strings.ReplaceAll(str, "'", "''") + "'"
+		}
+		return templateValue(node.value, node.datatype)
+	}
+
+	operator := node.operator
+	if operator == "!=" {
+		operator = "<>"
+	}
+
+	// text is ordered by bytes like the generator & app, not by the database collation
+	if node.datatype == "text" && operator != "=" && operator != "<>" {
+		return fmt.Sprintf(`(%s %s %s COLLATE "C")`, node.children[0].toSQL(), operator, node.children[1].toSQL())
+	}
+
+	return fmt.Sprintf("(%s %s %s)", node.children[0].toSQL(), operator, node.children[1].toSQL())
+}
+
+// go expression of the check used in generated app, item is the request body
+func (node *checkNode) toGo() string {
+	switch node.kind {
+	case "implies", "or", "and":
+		return fmt.Sprintf("check%s(%s, %s)", capitalize(node.kind), node.children[0].toGo(), node.children[1].toGo())
+	case "not":
+		return fmt.Sprintf("checkNot(%s)", node.children[0].toGo())
+	case "isNull":
+		return fmt.Sprintf("checkIf(!item.Column_%s.Valid)", node.children[0].name)
+	case "isNotNull":
+		return fmt.Sprintf("checkIf(item.Column_%s.Valid)", node.children[0].name)
+	case "column", "literal":
+		return fmt.Sprintf("checkBool(%s)", node.goOperand("boolean"))
+	}
+
+	compare := map[string]string{
+		"integer":     "cmp.Compare[int64]",
+		"real":        "cmp.Compare[float64]",
+		"text":        "strings.Compare",
+		"uuid":        "strings.Compare",
+		"boolean":     "compareBool",
+		"date":        "time.Time.Compare",
+		"time":        "time.Time.Compare",
+		"timestamptz": "time.Time.Compare",
+	}[node.datatype]
+
+	return fmt.Sprintf("checkCompare(%s, %s, %q, %s)", node.children[0].goOperand(node.datatype), node.children[1].goOperand(node.datatype), node.operator, compare)
+}
+
+// valid flag & value of the operand in generated app
+func (node *checkNode) goOperand(datatype string) string {
+	if node.kind == "column" {
+		value := fmt.Sprintf("item.Column_%s.GetValue()", node.name)
+		if datatype == "real" && node.datatype == "integer" {
+			value = fmt.Sprintf("float64(%s)", value)
+		}
+		return fmt.Sprintf("item.Column_%s.Valid, %s", node.name, value)
+	}
+
+	switch datatype {
+	case "integer":
+		return fmt.Sprintf("true, int64(%v)", node.value)
+	case "real":
+		return fmt.Sprintf("true, float64(%v)", node.value)
+	case "text", "uuid":
+		return fmt.Sprintf("true, %q", node.value)
+	case "date", "time", "timestamptz":
+		parsed, _ := node.value.(time.Time)
+		return fmt.Sprintf("true, parseTimeBound(%q, %q)", datetimeFormats[datatype], parsed.Format(datetimeFormats[datatype]))
+	}
+
+	return fmt.Sprintf("true, %v", node.value)
+}
+
+// parses & validates the table checks
+func (table *Table) setChecks() error {
+	table.checks = nil
+
+	for _, name := range slices.Sorted(maps.Keys(table.Checks)) {
+		if len(name) == 0 || name != sanitize_db_label(name) {
+			return fmt.Errorf("check name %s isn't sanitized", name)
+		}
+
+		expression := table.Checks[name]
+		root, err := parseCheck(expression)
+		if err != nil {
+			return fmt.Errorf("invalid %s check: %v", name, err)
+		}
+
+		if err := root.resolve(table.Columns); err != nil {
+			return fmt.Errorf("invalid %s check: %v", name, err)
+		}
+
+		if !root.isPredicate() {
+			return fmt.Errorf("invalid %s check: expression should be a condition", name)
+		}
+
+		table.checks = append(table.checks, tableCheck{name: name, expression: expression, root: root})
+	}
+
+	return nil
+}
+
+// validates a row against table checks, values are ordered as per headers
+// columns absent in headers take their default value parsed by validation
+func (table *Table) validateChecks(headers []string, values []any) error {
+	if len(table.checks) == 0 {
+		return nil
+	}
+
+	rowValues := make(map[string]any, len(table.Columns))
+	for columnName, column := range table.Columns {
+		rowValues[columnName] = column.defaultValue
+	}
+
+	for idx, header := range headers {
+		rowValues[header] = values[idx]
+	}
+
+	for _, check := range table.checks {
+		if check.root.evaluate(rowValues) == checkFalse {
+			return fmt.Errorf("%s check not satisfied: %s", check.name, check.expression)
+		}
+	}
+
+	return nil
+}
+
+// named CHECK constraints of the table
+func templateTableChecks(table Table) []string {
+	constraints := []string{}
+	for _, check := range table.checks {
+		constraints = append(constraints, fmt.Sprintf(`CONSTRAINT "%s" CHECK %s`, check.name, check.root.toSQL()))
+	}
+	return constraints
+}
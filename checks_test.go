@@ -0,0 +1,170 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTable_validateChecks(t *testing.T) {
+	columns := map[string]Column{
+		"start_date":      {ColumnName: "start_date", DataType: "date"},
+		"end_date":        {ColumnName: "end_date", DataType: "date"},
+		"lateral_allowed": {ColumnName: "lateral_allowed", DataType: "boolean"},
+		"course_id":       {ColumnName: "course_id", DataType: "text"},
+		"marks":           {ColumnName: "marks", DataType: "integer"},
+		"score":           {ColumnName: "score", DataType: "real"},
+	}
+
+	date1, _ := time.Parse(time.DateOnly, "2024-01-01")
+	date2, _ := time.Parse(time.DateOnly, "2024-02-01")
+
+	tests := []struct {
+		name       string
+		expression string
+		headers    []string
+		values     []any
+		wantErr    bool
+	}{
+		{
+			name:       "dates in order",
+			expression: "end_date >= start_date",
+			headers:    []string{"start_date", "end_date"},
+			values:     []any{date1, date2},
+			wantErr:    false,
+		},
+		{
+			name:       "dates out of order",
+			expression: "end_date >= start_date",
+			headers:    []string{"start_date", "end_date"},
+			values:     []any{date2, date1},
+			wantErr:    true,
+		},
+		{
+			name:       "null operand is unknown",
+			expression: "end_date >= start_date",
+			headers:    []string{"start_date", "end_date"},
+			values:     []any{date2, nil},
+			wantErr:    false,
+		},
+		{
+			name:       "implication violated",
+			expression: "lateral_allowed IMPLIES course_id IS NOT NULL",
+			headers:    []string{"lateral_allowed", "course_id"},
+			values:     []any{true, nil},
+			wantErr:    true,
+		},
+		{
+			name:       "implication with false premise",
+			expression: "lateral_allowed implies course_id is not null",
+			headers:    []string{"lateral_allowed"},
+			values:     []any{false},
+			wantErr:    false,
+		},
+		{
+			name:       "mixed numeric & literals",
+			expression: "(marks > 10 AND score <= marks) OR course_id = 'it''s'",
+			headers:    []string{"marks", "score", "course_id"},
+			values:     []any{int64(5), 2.5, "it's"},
+			wantErr:    false,
+		},
+		{
+			name:       "not",
+			expression: `NOT "course_id" <> 'c1'`,
+			headers:    []string{"course_id"},
+			values:     []any{"c2"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &Table{Columns: columns, Checks: map[string]string{"rule": tt.expression}}
+			if err := table.setChecks(); err != nil {
+				t.Fatalf("Table.setChecks() error = %v", err)
+			}
+			if err := table.validateChecks(tt.headers, tt.values); (err != nil) != tt.wantErr {
+				t.Errorf("Table.validateChecks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTable_validateChecks_defaults(t *testing.T) {
+	// defaults are read from schema.json as JSON values
+	table := &Table{
+		Columns: map[string]Column{
+			"start_date": {ColumnName: "start_date", DataType: "date", Default: "2024-03-01"},
+			"end_date":   {ColumnName: "end_date", DataType: "date"},
+			"marks":      {ColumnName: "marks", DataType: "smallint", Default: float64(40)},
+		},
+		Checks: map[string]string{"ordered": "end_date >= start_date", "passed": "marks >= 33"},
+	}
+	for columnName, column := range table.Columns {
+		if err := column.validateDefaultValue(); err != nil {
+			t.Fatalf("Column.validateDefaultValue() error = %v", err)
+		}
+		table.Columns[columnName] = column
+	}
+	if err := table.setChecks(); err != nil {
+		t.Fatalf("Table.setChecks() error = %v", err)
+	}
+
+	date1, _ := time.Parse(time.DateOnly, "2024-04-01")
+	date2, _ := time.Parse(time.DateOnly, "2024-02-01")
+
+	if err := table.validateChecks([]string{"end_date"}, []any{date1}); err != nil {
+		t.Errorf("Table.validateChecks() error = %v, want nil", err)
+	}
+	if err := table.validateChecks([]string{"end_date"}, []any{date2}); err == nil {
+		t.Errorf("Table.validateChecks() error = nil, want ordered check error")
+	}
+}
+
+func TestTable_setChecks(t *testing.T) {
+	columns := map[string]Column{
+		"start_date": {ColumnName: "start_date", DataType: "date"},
+		"marks":      {ColumnName: "marks", DataType: "integer"},
+		"tags":       {ColumnName: "tags", DataType: "text[]"},
+		"code":       {ColumnName: "code", DataType: "text"},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		wantSQL    string
+		wantErr    bool
+	}{
+		{
+			name:       "valid",
+			expression: "marks >= 0 AND start_date > '2024-01-01'",
+			wantSQL:    `CONSTRAINT "rule" CHECK (("marks" >= 0) AND ("start_date" > '2024-01-01'))`,
+		},
+		{
+			name:       "text order by bytes",
+			expression: "code >= 'A' AND code != 'Z'",
+			wantSQL:    `CONSTRAINT "rule" CHECK (("code" >= 'A' COLLATE "C") AND ("code" <> 'Z'))`,
+		},
+		{name: "unknown column", expression: "grade > 0", wantErr: true},
+		{name: "array column", expression: "tags IS NULL", wantErr: true},
+		{name: "type mismatch", expression: "marks > start_date", wantErr: true},
+		{name: "invalid literal", expression: "start_date > 'today'", wantErr: true},
+		{name: "non condition", expression: "marks", wantErr: true},
+		{name: "unbalanced", expression: "(marks > 0", wantErr: true},
+		{name: "trailing tokens", expression: "marks > 0 0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &Table{Columns: columns, Checks: map[string]string{"rule": tt.expression}}
+			err := table.setChecks()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Table.setChecks() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := templateTableChecks(*table); len(got) != 1 || got[0] != tt.wantSQL {
+				t.Errorf("templateTableChecks() = %v, want %v", got, tt.wantSQL)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// input formats of epoch seconds & milliseconds, only for timestamptz
+const (
+	unixFormat   = "unix"
+	unixMsFormat = "unix_ms"
+)
+
+// Go layouts tried during inference, ordered by preference. Canonical datetimeFormats come first
+// and day-first layouts precede month-first ones, so 01/07/2024 is 1st July.
+var datetimeLayouts = map[string][]string{
+	"date": {
+		time.DateOnly, "2/1/2006", "1/2/2006", "2-1-2006", "1-2-2006", "2006/1/2", "2.1.2006",
+		"2 Jan 2006", "Jan 2, 2006", "2 January 2006", "January 2, 2006",
+	},
+	"time": {
+		time.TimeOnly, "15:04", "3:04:05 PM", "3:04 PM", "3:04PM",
+	},
+	"timestamptz": {
+		time.RFC3339, "2006-01-02 15:04:05Z07:00", "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04",
+		"2006-01-02 15:04", "2/1/2006 15:04:05", "2/1/2006 15:04", "1/2/2006 15:04:05", "1/2/2006 15:04",
+		time.RFC1123Z, time.RFC1123,
+	},
+}
+
+var epochRegex = regexp.MustCompile(`^\d{10}(\d{3})?$`)
+
+// integer columns with such names are inferred as epochs
+var epochHeaderRegex = regexp.MustCompile(`(?i)(^|_)(at|on|time|timestamp|ts|epoch|date|datetime)$`)
+
+func isDatetimeType(datatype string) bool {
+	_, ok := datetimeFormats[datatype]
+	return ok
+}
+
+// parses the value with the layout in location, canonical format is used if layout is empty
+func parseDatetime(value, datatype, layout string, location *time.Location) (time.Time, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	if layout == "" {
+		layout = datetimeFormats[datatype]
+	}
+
+	if layout == unixFormat || layout == unixMsFormat {
+		epoch, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if layout == unixMsFormat {
+			return time.UnixMilli(epoch).In(location), nil
+		}
+		return time.Unix(epoch, 0).In(location), nil
+	}
+
+	parsed, err := time.ParseInLocation(layout, value, location)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// date & time values are stored without zone
+	if datatype != "timestamptz" {
+		parsed = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), parsed.Nanosecond(), time.UTC)
+	}
+
+	return parsed, nil
+}
+
+// returns the datetime type of value & all the layouts parsing it, empty datatype if none
+func detectDatetime(value string) (string, []string) {
+	for _, datatype := range []string{"date", "time", "timestamptz"} {
+		if layouts := filterDatetimeLayouts(value, datatype, datetimeLayouts[datatype]); len(layouts) > 0 {
+			return datatype, layouts
+		}
+	}
+	return "", nil
+}
+
+// returns the layouts which parse the value, array values must be parsed by the same layout
+func filterDatetimeLayouts(value, datatype string, layouts []string) []string {
+	values := []string{value}
+
+	if strings.HasSuffix(datatype, "[]") {
+		arr, err := parseArray(value, datatype)
+		if err != nil {
+			return nil
+		}
+		values = values[:0]
+		for _, item := range flattenArray(arr) {
+			values = append(values, fmt.Sprintf("%v", item))
+		}
+		datatype = elementType(datatype)
+	}
+
+	res := []string{}
+	for _, layout := range layouts {
+		ok := true
+		for _, item := range values {
+			if _, err := parseDatetime(item, datatype, layout, time.UTC); err != nil {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			res = append(res, layout)
+		}
+	}
+
+	return res
+}
+
+// epoch format of the value, empty if it isn't an epoch
+func detectEpoch(value string) string {
+	match := epochRegex.FindStringSubmatch(value)
+	if match == nil {
+		return ""
+	}
+	if match[1] != "" {
+		return unixMsFormat
+	}
+	return unixFormat
+}
+
+// validates InputFormat & resolves the timezone of datetime columns
+func (column *Column) setInputFormat(defaultTimezone string) error {
+	column.InputFormat = strings.TrimSpace(column.InputFormat)
+	column.Timezone = strings.TrimSpace(column.Timezone)
+	datatype := elementType(column.DataType)
+
+	if !isDatetimeType(datatype) {
+		if column.InputFormat != "" || column.Timezone != "" {
+			return errors.New("inputFormat & timezone are allowed only for date, time & timestamptz columns")
+		}
+		return nil
+	}
+
+	timezone := column.Timezone
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %s", timezone)
+	}
+	column.location = location
+
+	switch column.InputFormat {
+	case "":
+		return nil
+	case unixFormat, unixMsFormat:
+		if datatype != "timestamptz" {
+			return fmt.Errorf("%s inputFormat requires a timestamptz column", column.InputFormat)
+		}
+		return nil
+	}
+
+	// the layout should be able to parse its own output
+	reference := time.Date(2024, 7, 1, 12, 30, 45, 0, location)
+	if _, err := time.ParseInLocation(column.InputFormat, reference.Format(column.InputFormat), location); err != nil ||
+		column.InputFormat == reference.Format(column.InputFormat) {
+		return fmt.Errorf("invalid inputFormat %s, should be a Go time layout, %s or %s", column.InputFormat, unixFormat, unixMsFormat)
+	}
+
+	return nil
+}
+
+// parses CSV values with the parse options, datetime ones with InputFormat falling back to the canonical format
+func (column *Column) parseInput(value any, datatype string) (any, bool) {
+	str, ok := value.(string)
+	if !ok {
+		return validateValueByType(value, datatype)
+	}
+
+	str = column.normalizeInput(strings.TrimSpace(str), datatype)
+	if !isDatetimeType(datatype) || len(str) == 0 || (column.InputFormat == "" && column.location == nil) {
+		return validateValueByType(str, datatype)
+	}
+
+	if column.InputFormat != "" {
+		if parsed, err := parseDatetime(str, datatype, column.InputFormat, column.location); err == nil {
+			return parsed, true
+		}
+	}
+
+	parsed, err := parseDatetime(str, datatype, "", column.location)
+	if err != nil {
+		return nil, false
+	}
+
+	return parsed, true
+}
+
+// sets InputFormat of inferred datetime columns & converts epoch columns, layouts are keyed by column names
+func (table *Table) setInferredFormats(layouts map[string][]string, epochs map[string]string) {
+	for columnName, column := range table.Columns {
+		datatype := elementType(column.DataType)
+
+		if candidates := layouts[columnName]; isDatetimeType(datatype) && len(candidates) > 0 &&
+			!slices.Contains(candidates, datetimeFormats[datatype]) {
+			column.InputFormat = candidates[0]
+		}
+
+		if epoch := epochs[columnName]; (epoch == unixFormat || epoch == unixMsFormat) &&
+			isIntegerType(column.DataType) && epochHeaderRegex.MatchString(columnName) {
+			column.DataType = "timestamptz"
+			column.InputFormat = epoch
+		}
+
+		table.Columns[columnName] = column
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_setColumnTypes_inputFormats(t *testing.T) {
+	data := `day,slot,created_at,iso,mixed
+01/07/2024,14:30,1719837000,2024-07-01T12:30:00+05:30,2024-01-01
+13/07/2024,9:05,1719923400,,01/02/2024
+`
+	reader := &csvReader{Reader: csv.NewReader(strings.NewReader(data))}
+	headers, _ := reader.Read()
+
+	table := &Table{Columns: map[string]Column{}}
+	for _, header := range headers {
+		table.Columns[header] = Column{ColumnName: header}
+	}
+
+	if err := setColumnTypes(reader, table, headers, &ParseOptions{}); err != nil {
+		t.Fatalf("setColumnTypes() error = %v", err)
+	}
+
+	want := map[string][2]string{
+		"day":        {"date", "2/1/2006"},
+		"slot":       {"time", "15:04"},
+		"created_at": {"timestamptz", unixFormat},
+		"iso":        {"timestamptz", ""},
+		"mixed":      {"text", ""},
+	}
+	for columnName, wantColumn := range want {
+		column := table.Columns[columnName]
+		if column.DataType != wantColumn[0] || column.InputFormat != wantColumn[1] {
+			t.Errorf("%s column = %v %v, want %v %v", columnName, column.DataType, column.InputFormat, wantColumn[0], wantColumn[1])
+		}
+	}
+}
+
+func TestColumn_parseInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   Column
+		timezone string
+		value    string
+		want     string
+		wantOk   bool
+	}{
+		{
+			name:   "day first date",
+			column: Column{DataType: "date", InputFormat: "2/1/2006"},
+			value:  "01/07/2024",
+			want:   "'2024-07-01'",
+			wantOk: true,
+		},
+		{
+			name:   "canonical fallback",
+			column: Column{DataType: "date", InputFormat: "2/1/2006"},
+			value:  "2024-07-01",
+			want:   "'2024-07-01'",
+			wantOk: true,
+		},
+		{
+			name:     "timestamp without offset",
+			column:   Column{DataType: "timestamptz", InputFormat: "2006-01-02 15:04"},
+			timezone: "Asia/Kolkata",
+			value:    "2024-07-01 12:30",
+			want:     "'2024-07-01T12:30:00+05:30'",
+			wantOk:   true,
+		},
+		{
+			name:   "epoch millis",
+			column: Column{DataType: "timestamptz", InputFormat: unixMsFormat},
+			value:  "1719837000000",
+			want:   "'2024-07-01T12:30:00Z'",
+			wantOk: true,
+		},
+		{
+			name:   "invalid",
+			column: Column{DataType: "date", InputFormat: "2/1/2006"},
+			value:  "07/13/2024",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column := tt.column
+			if err := column.setInputFormat(tt.timezone); err != nil {
+				t.Fatalf("Column.setInputFormat() error = %v", err)
+			}
+			got, ok := column.parseInput(tt.value, column.DataType)
+			if ok != tt.wantOk {
+				t.Fatalf("Column.parseInput() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && templateValue(got, column.DataType) != tt.want {
+				t.Errorf("Column.parseInput() = %v, want %v", templateValue(got, column.DataType), tt.want)
+			}
+		})
+	}
+}
+
+func TestColumn_setInputFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  Column
+		wantErr bool
+	}{
+		{name: "layout", column: Column{DataType: "date[]", InputFormat: "02.01.2006"}},
+		{name: "not a layout", column: Column{DataType: "date", InputFormat: "DD/MM/YYYY"}, wantErr: true},
+		{name: "epoch date", column: Column{DataType: "date", InputFormat: unixFormat}, wantErr: true},
+		{name: "text column", column: Column{DataType: "text", InputFormat: "2/1/2006"}, wantErr: true},
+		{name: "invalid timezone", column: Column{DataType: "timestamptz", Timezone: "Mars/Base"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column := tt.column
+			if err := column.setInputFormat("UTC"); (err != nil) != tt.wantErr {
+				t.Errorf("Column.setInputFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_parseDatetime_zoneless(t *testing.T) {
+	location, _ := time.LoadLocation("Asia/Kolkata")
+	got, err := parseDatetime("01/07/2024", "date", "2/1/2006", location)
+	if err != nil || got.Location() != time.UTC || got.Day() != 1 || got.Month() != time.July {
+		t.Errorf("parseDatetime() = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// policies for rows sharing a primary key (Table.Dedup)
+//
+//	fail:  the insertion fails listing all the duplicates, or they're rejected in reject mode, default except for derived tables
+//	first: the first row is kept, later ones are dropped, default for derived tables
+//	last:  the last valid row is kept, earlier ones are dropped & later invalid ones are left to validation
+//	merge: non-empty fields of later rows overwrite the earlier ones, the merged row takes the place of the last
+var dedupPolicies = []string{"fail", "first", "last", "merge"}
+
+func (table *Table) validateDedup() error {
+	table.Dedup = strings.ToLower(strings.TrimSpace(table.Dedup))
+	if table.Dedup == "" {
+		table.Dedup = "fail"
+		if table.DerivedFrom != "" {
+			table.Dedup = "first"
+		}
+	}
+
+	if !slices.Contains(dedupPolicies, table.Dedup) {
+		return fmt.Errorf("%s should be one of %v", table.Dedup, dedupPolicies)
+	}
+
+	if table.Dedup != "fail" && table.PrimaryKey == "" {
+		return fmt.Errorf("%s requires a primary key", table.Dedup)
+	}
+
+	return nil
+}
+
+// handles the rows sharing a primary key during insertion of a table
+type deduper struct {
+	table      *Table
+	headers    []string
+	reject     bool                // duplicates are rejected instead of failing
+	keyIdx     int                 // index of the primary key in headers, -1 if it isn't read from the source
+	seen       map[string]int      // key: primary key value, value: row no. of the inserted row, for fail & first
+	last       map[string]int      // row no. of the last row of keys found in multiple rows, the last valid one for last
+	merged     map[string][]string // fields merged so far, for merge
+	current    string              // key of the admitted row
+	duplicates []string            // duplicates found with fail policy
+	dropped    int                 // rows dropped or merged into others
+}
+
+// returns the deduper of table, the source is scanned beforehand to find the last rows of keys for last & merge
+// headers are the column names of transformed rows
+func newDeduper(basePath string, table *Table, headers []string, reject bool) (*deduper, error) {
+	dedup := &deduper{
+		table:   table,
+		headers: headers,
+		reject:  reject,
+		keyIdx:  -1,
+		seen:    map[string]int{},
+		last:    map[string]int{},
+		merged:  map[string][]string{},
+	}
+
+	if table.PrimaryKey != "" {
+		dedup.keyIdx = slices.Index(headers, table.PrimaryKey)
+	}
+
+	if dedup.keyIdx == -1 || (table.Dedup != "last" && table.Dedup != "merge") {
+		return dedup, nil
+	}
+
+	// a copy keeps the shards of the insertion reader intact
+	scan := *table
+	reader, err := scan.openRows(basePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for rowIdx := 2; ; rowIdx++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// errors are reported during insertion
+		row, err := scan.transformRow(headers, fields)
+		if err != nil {
+			continue
+		}
+
+		if key := dedup.key(row); key != "" {
+			counts[key]++
+			if table.Dedup == "merge" || scan.validRow(headers, row) {
+				dedup.last[key] = rowIdx
+			}
+		}
+	}
+
+	maps.DeleteFunc(dedup.last, func(key string, _ int) bool {
+		return counts[key] < 2
+	})
+
+	return dedup, nil
+}
+
+// returns the parsed primary key value of a transformed row, empty if it's null or invalid
+func (dedup *deduper) key(row []string) string {
+	if dedup.keyIdx == -1 {
+		return ""
+	}
+
+	column := dedup.table.Columns[dedup.table.PrimaryKey]
+	value := strings.TrimSpace(row[dedup.keyIdx])
+	if value == "" || column.parsing.isNull(value) {
+		return ""
+	}
+
+	parsed, ok := column.parseInput(value, column.DataType)
+	if !ok {
+		return ""
+	}
+
+	return templateValue(parsed, column.DataType)
+}
+
+// returns the fields & the transformed row to insert, false if the row is dropped or held for merging
+// duplicates of fail policy are returned as error without a row in reject mode
+func (dedup *deduper) admit(rowIdx int, fields, row []string) ([]string, []string, bool, error) {
+	key := dedup.key(row)
+	dedup.current = key
+
+	// rows of derived tables without a key don't make a row
+	if key == "" {
+		return fields, row, dedup.table.DerivedFrom == "", nil
+	}
+
+	if lastIdx, ok := dedup.last[key]; ok {
+		if dedup.table.Dedup == "merge" {
+			dedup.merged[key] = mergeFields(dedup.merged[key], fields)
+		}
+
+		// later rows of last policy are invalid, validation rejects them instead of dropping the key
+		if rowIdx > lastIdx && dedup.table.Dedup == "last" {
+			return fields, row, true, nil
+		}
+
+		if rowIdx != lastIdx {
+			dedup.dropped++
+			return fields, row, false, nil
+		}
+
+		if dedup.table.Dedup == "merge" {
+			fields = dedup.merged[key]
+			delete(dedup.merged, key)
+
+			row, err := dedup.table.transformRow(dedup.headers, fields)
+			return fields, row, err == nil, err
+		}
+
+		return fields, row, true, nil
+	}
+
+	firstIdx, ok := dedup.seen[key]
+	if !ok {
+		return fields, row, true, nil
+	}
+
+	if dedup.table.Dedup == "first" {
+		dedup.dropped++
+		return fields, row, false, nil
+	}
+
+	duplicate := fmt.Sprintf("duplicate %s %s of %s", dedup.table.PrimaryKey, key, dedup.table.rowLocation(firstIdx))
+	if dedup.reject {
+		return fields, nil, false, errors.New(duplicate)
+	}
+
+	dedup.duplicates = append(dedup.duplicates, fmt.Sprintf("%s: %s", dedup.table.rowLocation(rowIdx), duplicate))
+	return fields, row, false, nil
+}
+
+// true if the transformed row satisfies the column constraints & checks, unique values aren't recorded
+func (table *Table) validRow(headers, row []string) bool {
+	values := make([]any, len(headers))
+	for idx, value := range row {
+		column := table.Columns[headers[idx]]
+		column.Unique = false
+
+		val, err := column.validateValueByConstraints(value, true)
+		if err != nil {
+			return false
+		}
+		values[idx] = val
+	}
+
+	return table.validateChecks(headers, values) == nil
+}
+
+// records the key of the admitted row once it's inserted
+func (dedup *deduper) accept(rowIdx int) {
+	if dedup.current != "" {
+		dedup.seen[dedup.current] = rowIdx
+	}
+}
+
+// returns the duplicates found with fail policy
+func (dedup *deduper) err() error {
+	if len(dedup.duplicates) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d rows of %s table share a primary key with an earlier row:\n%s",
+		len(dedup.duplicates), dedup.table.TableName, strings.Join(dedup.duplicates, "\n"))
+}
+
+// overwrites the fields with the non-empty ones of next
+func mergeFields(fields, next []string) []string {
+	if fields == nil {
+		return slices.Clone(next)
+	}
+
+	for idx, value := range next {
+		if strings.TrimSpace(value) != "" {
+			fields[idx] = value
+		}
+	}
+
+	return fields
+}
+
+// returns the summary of rows dropped or merged by dedup policies
+func (dbSchema *DB) dedupReport() []string {
+	report := []string{}
+
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		table := dbSchema.Tables[tableName]
+		// repeated rows make up derived tables
+		if table.rows == nil || table.rows.dropped == 0 || table.DerivedFrom != "" {
+			continue
+		}
+
+		if table.Dedup == "merge" {
+			report = append(report, fmt.Sprintf("%d duplicate rows of %s table merged", table.rows.dropped, tableName))
+		} else {
+			report = append(report, fmt.Sprintf("%d duplicate rows of %s table dropped, the %s ones are kept", table.rows.dropped, tableName, table.Dedup))
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_validateDedup(t *testing.T) {
+	tests := []struct {
+		name       string
+		dedup      string
+		primaryKey string
+		want       string
+		wantErr    bool
+	}{
+		{name: "default", primaryKey: "id", want: "fail"},
+		{name: "case", dedup: " Last ", primaryKey: "id", want: "last"},
+		{name: "fail without key", dedup: "fail", want: "fail"},
+		{name: "merge without key", dedup: "merge", wantErr: true},
+		{name: "invalid", dedup: "skip", primaryKey: "id", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &Table{Dedup: tt.dedup, PrimaryKey: tt.primaryKey}
+			err := table.validateDedup()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateDedup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && table.Dedup != tt.want {
+				t.Errorf("validateDedup() Dedup = %v, want %v", table.Dedup, tt.want)
+			}
+		})
+	}
+}
+
+func Test_mergeFields(t *testing.T) {
+	merged := mergeFields(nil, []string{"1", "Ram", ""})
+	merged = mergeFields(merged, []string{"1", " ", "ram@mail.com"})
+	merged = mergeFields(merged, []string{"1", "Ram Kumar", ""})
+
+	if want := []string{"1", "Ram Kumar", "ram@mail.com"}; !reflect.DeepEqual(merged, want) {
+		t.Errorf("mergeFields() = %v, want %v", merged, want)
+	}
+}
+
+func Test_deduper_admit(t *testing.T) {
+	newDedup := func(policy string, reject bool) *deduper {
+		table := &Table{
+			TableName:  "students",
+			PrimaryKey: "id",
+			Dedup:      policy,
+			Columns: map[string]Column{
+				"id":   {ColumnName: "id", DataType: "integer", parsing: ParseOptions{NullTokens: []string{"NA"}}},
+				"name": {ColumnName: "name", DataType: "text"},
+			},
+		}
+		return &deduper{table: table, headers: []string{"id", "name"}, reject: reject, keyIdx: 0, seen: map[string]int{}, last: map[string]int{}, merged: map[string][]string{}}
+	}
+
+	rows := [][]string{{"1", "Ram"}, {"2", "Shyam"}, {"01", "Ram Kumar"}, {"NA", "Mohan"}, {"NA", "Sohan"}}
+
+	// returns the row no. of the admitted rows
+	admitAll := func(dedup *deduper) ([]int, error) {
+		admitted := []int{}
+		for idx, row := range rows {
+			_, _, ok, err := dedup.admit(idx+2, row, row)
+			if err != nil {
+				return admitted, err
+			}
+			if ok {
+				dedup.accept(idx + 2)
+				admitted = append(admitted, idx+2)
+			}
+		}
+		return admitted, nil
+	}
+
+	dedup := newDedup("first", false)
+	if got, _ := admitAll(dedup); !reflect.DeepEqual(got, []int{2, 3, 5, 6}) || dedup.dropped != 1 {
+		t.Errorf("first: admitted = %v, dropped = %d", got, dedup.dropped)
+	}
+
+	dedup = newDedup("fail", false)
+	admitAll(dedup)
+	if err := dedup.err(); err == nil || !strings.Contains(err.Error(), "row no. 4: duplicate id 1 of row no. 2") {
+		t.Errorf("fail: err() = %v", err)
+	}
+
+	dedup = newDedup("fail", true)
+	if _, err := admitAll(dedup); err == nil || err.Error() != "duplicate id 1 of row no. 2" {
+		t.Errorf("fail with reject: admit() error = %v", err)
+	}
+
+	dedup = newDedup("last", false)
+	dedup.last["1"] = 4
+	if got, _ := admitAll(dedup); !reflect.DeepEqual(got, []int{3, 4, 5, 6}) || dedup.dropped != 1 {
+		t.Errorf("last: admitted = %v, dropped = %d", got, dedup.dropped)
+	}
+
+	// row no. 4 is invalid, the earlier valid row is kept & row no. 4 is left to validation
+	dedup = newDedup("last", true)
+	dedup.last["1"] = 2
+	if got, _ := admitAll(dedup); !reflect.DeepEqual(got, []int{2, 3, 4, 5, 6}) || dedup.dropped != 0 {
+		t.Errorf("last with invalid row: admitted = %v, dropped = %d", got, dedup.dropped)
+	}
+}
+
+func Test_validRow(t *testing.T) {
+	table := &Table{
+		Columns: map[string]Column{
+			"id":    {ColumnName: "id", DataType: "integer", NotNull: true, Unique: true, values: map[string]bool{}},
+			"marks": {ColumnName: "marks", DataType: "integer"},
+		},
+		Checks: map[string]string{"passed": "marks >= 33"},
+	}
+	if err := table.setChecks(); err != nil {
+		t.Fatal(err)
+	}
+
+	headers := []string{"id", "marks"}
+	tests := []struct {
+		row  []string
+		want bool
+	}{
+		{row: []string{"1", "90"}, want: true},
+		{row: []string{"1", "80"}, want: true},
+		{row: []string{"", "90"}, want: false},
+		{row: []string{"x", "90"}, want: false},
+		{row: []string{"2", "20"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := table.validRow(headers, tt.row); got != tt.want {
+			t.Errorf("validRow(%v) = %v, want %v", tt.row, got, tt.want)
+		}
+	}
+
+	if len(table.Columns["id"].values) != 0 {
+		t.Errorf("validRow() recorded unique values %v", table.Columns["id"].values)
+	}
+}
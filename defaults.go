@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// default expressions (Column.DefaultExpr) along with the datatype they apply to
+var defaultExprTypes = map[string]string{
+	"now()":             "timestamptz",
+	"current_date":      "date",
+	"current_time":      "time",
+	"gen_random_uuid()": "text",
+	"jwt_user":          "text", // username of the JWT, set by generated app instead of database
+}
+
+var sequenceExprRegex = regexp.MustCompile(`^nextval\('([a-zA-Z0-9_]+)'\)$`)
+
+var primaryKeyStrategies = []string{"serial", "identity", "bigserial", "uuid_v4", "uuid_v7"}
+
+// validates DefaultExpr & sets the SQL default of the column
+func (column *Column) setDefaultExpr() error {
+	column.DefaultExpr = strings.TrimSpace(column.DefaultExpr)
+	column.defaultSQL = ""
+	column.sequence = ""
+
+	expr := strings.ToLower(column.DefaultExpr)
+	if expr == "" {
+		return nil
+	}
+
+	if column.Default != nil {
+		return errors.New("default and defaultExpr can't be used together")
+	}
+
+	if column.Hash {
+		return errors.New("defaultExpr can't be used with hash")
+	}
+
+	if matches := sequenceExprRegex.FindStringSubmatch(column.DefaultExpr); matches != nil {
+		if !isIntegerType(column.DataType) {
+			return errors.New("nextval() requires an integer column")
+		}
+
+		column.sequence = matches[1]
+		column.defaultSQL = fmt.Sprintf(`DEFAULT nextval('"%s"')`, column.sequence)
+		return nil
+	}
+
+	datatype, ok := defaultExprTypes[expr]
+	if !ok {
+		return fmt.Errorf("unsupported defaultExpr %s", column.DefaultExpr)
+	}
+
+	// uuids are stored natively in uuid columns
+	if expr == "gen_random_uuid()" && column.DataType == "uuid" {
+		datatype = "uuid"
+	}
+
+	if column.DataType != datatype {
+		return fmt.Errorf("%s requires a %s column", expr, datatype)
+	}
+
+	column.DefaultExpr = expr
+
+	switch expr {
+	case "current_time":
+		column.defaultSQL = "DEFAULT LOCALTIME"
+	case "gen_random_uuid()":
+		column.defaultSQL = "DEFAULT gen_random_uuid()"
+		if datatype == "text" {
+			column.defaultSQL += "::text"
+		}
+	case "jwt_user":
+	default:
+		column.defaultSQL = "DEFAULT " + expr
+	}
+
+	return nil
+}
+
+// validates PrimaryKeyStrategy & sets the generated primary key column
+func (table *Table) setPrimaryKeyStrategy() error {
+	table.PrimaryKeyStrategy = strings.ToLower(strings.TrimSpace(table.PrimaryKeyStrategy))
+	strategy := table.PrimaryKeyStrategy
+
+	if strategy == "" {
+		return nil
+	}
+
+	if !slices.Contains(primaryKeyStrategies, strategy) {
+		return fmt.Errorf("invalid primaryKeyStrategy %s, should be one of %v", strategy, primaryKeyStrategies)
+	}
+
+	// __ID column
+	if table.PrimaryKey == "" {
+		if strings.HasPrefix(strategy, "uuid") {
+			return errors.New("uuid strategies require a text or uuid primary key")
+		}
+		return nil
+	}
+
+	column := table.Columns[table.PrimaryKey]
+	if column.Default != nil || column.DefaultExpr != "" {
+		return errors.New("primary key with a strategy can't have a default")
+	}
+
+	switch strategy {
+	case "serial":
+		return errors.New("serial strategy is used only for tables without primary key")
+	case "identity", "bigserial":
+		if !isIntegerType(column.DataType) {
+			return fmt.Errorf("%s strategy requires an integer primary key", strategy)
+		}
+		if strategy == "identity" {
+			column.defaultSQL = "GENERATED BY DEFAULT AS IDENTITY"
+		}
+	case "uuid_v4", "uuid_v7":
+		if column.DataType != "text" && column.DataType != "uuid" {
+			return fmt.Errorf("%s strategy requires a text or uuid primary key", strategy)
+		}
+		column.defaultSQL = "DEFAULT gen_random_uuid()"
+		if strategy == "uuid_v7" {
+			column.defaultSQL = "DEFAULT uuid_generate_v7()"
+		}
+		if column.DataType == "text" {
+			column.defaultSQL += "::text"
+		}
+	}
+
+	column.pkStrategy = strategy
+	table.Columns[table.PrimaryKey] = column
+
+	return nil
+}
+
+// true if the value is generated by database, such columns are omitted by generated app inserts
+func (column Column) IsGenerated() bool {
+	return column.defaultSQL != "" || column.pkStrategy != ""
+}
+
+// SQL following the column type, i.e. default expression or identity
+func (column Column) GeneratedSQL() string {
+	return column.defaultSQL
+}
+
+// "__ID" column of tables without primary key
+func templateIDColumn(table Table) string {
+	switch table.PrimaryKeyStrategy {
+	case "identity":
+		return `"__ID" integer GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY`
+	case "bigserial":
+		return `"__ID" BIGSERIAL PRIMARY KEY`
+	}
+	return `"__ID" SERIAL PRIMARY KEY`
+}
+
+// sequences referenced by nextval() defaults, sorted by name
+func (dbSchema *DB) getSequences() []string {
+	sequences := []string{}
+	for _, table := range dbSchema.Tables {
+		for _, column := range table.Columns {
+			if column.sequence != "" && !slices.Contains(sequences, column.sequence) {
+				sequences = append(sequences, column.sequence)
+			}
+		}
+	}
+	slices.Sort(sequences)
+	return sequences
+}
+
+func (dbSchema *DB) usesUUIDv7() bool {
+	for _, table := range dbSchema.Tables {
+		if table.PrimaryKey != "" && table.PrimaryKeyStrategy == "uuid_v7" {
+			return true
+		}
+	}
+	return false
+}
+
+// advances the sequences past the explicit values inserted from CSV
+func templateSequenceReset(table *Table, headers []string) string {
+	res := ""
+
+	if slices.Contains(headers, table.PrimaryKey) && (table.PrimaryKeyStrategy == "identity" || table.PrimaryKeyStrategy == "bigserial") {
+		res += fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('"%s"', '%s'), (SELECT COALESCE(MAX("%s"), 0) + 1 FROM "%s"), false);`+"\n",
+			table.TableName, table.PrimaryKey, table.PrimaryKey, table.TableName)
+	}
+
+	for _, columnName := range headers {
+		sequence := table.Columns[columnName].sequence
+		if sequence == "" {
+			continue
+		}
+
+		// sequences may be shared, hence never moved backwards
+		res += fmt.Sprintf(`SELECT setval('"%s"', GREATEST((SELECT last_value FROM "%s"), (SELECT COALESCE(MAX("%s"), 0) FROM "%s")));`+"\n",
+			sequence, sequence, columnName, table.TableName)
+	}
+
+	return res
+}
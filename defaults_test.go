@@ -0,0 +1,81 @@
+package main
+
+import "testing"
+
+func TestColumn_setDefaultExpr(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  Column
+		wantSQL string
+		wantErr bool
+	}{
+		{name: "now", column: Column{DataType: "timestamptz", DefaultExpr: "NOW()"}, wantSQL: "DEFAULT now()"},
+		{name: "current_time", column: Column{DataType: "time", DefaultExpr: "current_time"}, wantSQL: "DEFAULT LOCALTIME"},
+		{name: "uuid", column: Column{DataType: "text", DefaultExpr: "gen_random_uuid()"}, wantSQL: "DEFAULT gen_random_uuid()::text"},
+		{name: "jwt user", column: Column{DataType: "text", DefaultExpr: "jwt_user"}, wantSQL: ""},
+		{name: "sequence", column: Column{DataType: "integer", DefaultExpr: "nextval('student_seq')"}, wantSQL: `DEFAULT nextval('"student_seq"')`},
+		{name: "sequence on text", column: Column{DataType: "text", DefaultExpr: "nextval('student_seq')"}, wantErr: true},
+		{name: "type mismatch", column: Column{DataType: "date", DefaultExpr: "now()"}, wantErr: true},
+		{name: "unsupported", column: Column{DataType: "text", DefaultExpr: "random()"}, wantErr: true},
+		{name: "with default", column: Column{DataType: "date", DefaultExpr: "current_date", Default: "2024-01-01"}, wantErr: true},
+		{name: "with hash", column: Column{DataType: "text", DefaultExpr: "gen_random_uuid()", Hash: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column := tt.column
+			err := column.setDefaultExpr()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Column.setDefaultExpr() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && column.GeneratedSQL() != tt.wantSQL {
+				t.Errorf("Column.GeneratedSQL() = %v, want %v", column.GeneratedSQL(), tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestTable_setPrimaryKeyStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		pkType   string
+		noPK     bool
+		wantType string
+		wantSQL  string
+		wantErr  bool
+	}{
+		{name: "identity", strategy: "identity", pkType: "integer", wantType: "integer", wantSQL: "GENERATED BY DEFAULT AS IDENTITY"},
+		{name: "bigserial", strategy: "BigSerial", pkType: "integer", wantType: "bigserial"},
+		{name: "uuid_v7", strategy: "uuid_v7", pkType: "text", wantType: "text", wantSQL: "DEFAULT uuid_generate_v7()::text"},
+		{name: "uuid on integer", strategy: "uuid_v4", pkType: "integer", wantErr: true},
+		{name: "identity on text", strategy: "identity", pkType: "text", wantErr: true},
+		{name: "serial with primary key", strategy: "serial", pkType: "integer", wantErr: true},
+		{name: "uuid without primary key", strategy: "uuid_v4", noPK: true, wantErr: true},
+		{name: "invalid", strategy: "auto", pkType: "integer", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &Table{
+				PrimaryKey:         "id",
+				PrimaryKeyStrategy: tt.strategy,
+				Columns:            map[string]Column{"id": {ColumnName: "id", DataType: tt.pkType}},
+			}
+			if tt.noPK {
+				table.PrimaryKey = ""
+			}
+			err := table.setPrimaryKeyStrategy()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Table.setPrimaryKeyStrategy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr || tt.noPK {
+				return
+			}
+			column := table.Columns["id"]
+			if column.SQLType() != tt.wantType || column.GeneratedSQL() != tt.wantSQL || !column.IsGenerated() {
+				t.Errorf("primary key = %v %v, want %v %v", column.SQLType(), column.GeneratedSQL(), tt.wantType, tt.wantSQL)
+			}
+		})
+	}
+}
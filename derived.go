@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// derived tables (Table.DerivedFrom) are read from the source of another table, e.g. colleges from students.csv
+// their rows are the distinct values of their columns, keyed by the primary key
+// the tables sharing a source skip the columns of each other while reading it
+
+// sets the source of derived tables & the columns each table of a shared source skips
+func (dbSchema *DB) setDerivedTables() error {
+	families := map[string][]string{} // key: table owning the source, value: tables reading it
+
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		table := dbSchema.Tables[tableName]
+
+		root, err := dbSchema.derivationRoot(tableName)
+		if err != nil {
+			return err
+		}
+		families[root] = append(families[root], tableName)
+
+		if root == tableName {
+			continue
+		}
+
+		if table.PrimaryKey == "" {
+			return fmt.Errorf("derived table %s requires a primary key", tableName)
+		}
+
+		source := dbSchema.Tables[root]
+		table.FileName, table.Files, table.Format, table.Sheet, table.Entry, table.Headers = source.FileName, source.Files, source.Format, source.Sheet, source.Entry, source.Headers
+		dbSchema.Tables[tableName] = table
+	}
+
+	for _, members := range families {
+		if len(members) == 1 {
+			continue
+		}
+
+		columns := map[string]Column{}
+		for _, tableName := range members {
+			maps.Copy(columns, dbSchema.Tables[tableName].Columns)
+		}
+
+		for _, tableName := range members {
+			table := dbSchema.Tables[tableName]
+			table.extracted = map[string]Column{}
+			for columnName, column := range columns {
+				if _, ok := table.Columns[columnName]; !ok {
+					table.extracted[columnName] = column
+				}
+			}
+			dbSchema.Tables[tableName] = table
+		}
+	}
+
+	return nil
+}
+
+// returns the table owning the source of tableName, itself unless it's derived
+func (dbSchema *DB) derivationRoot(tableName string) (string, error) {
+	for range len(dbSchema.Tables) {
+		table := dbSchema.Tables[tableName]
+		if table.DerivedFrom == "" {
+			return tableName, nil
+		}
+
+		if _, ok := dbSchema.Tables[table.DerivedFrom]; !ok {
+			return "", fmt.Errorf("derivedFrom %s of table %s not found", table.DerivedFrom, tableName)
+		}
+		tableName = table.DerivedFrom
+	}
+
+	return "", fmt.Errorf("derivedFrom of table %s forms a cycle", tableName)
+}
+
+// true if the header is a column of another table sharing the source
+func (table *Table) isExtractedHeader(header string) bool {
+	if _, ok := table.headerColumn(header); ok || len(table.extracted) == 0 {
+		return false
+	}
+
+	if _, ok := table.extracted[table.headerColumnName(header)]; ok {
+		return true
+	}
+
+	label := headerLabel(header)
+	for _, column := range table.extracted {
+		if column.Label == label {
+			return true
+		}
+	}
+	return false
+}
+
+// opens the source of table, the fields of other tables sharing the source are skipped
+func (table *Table) openRows(basePath string) (sourceReader, error) {
+	reader, err := openSource(basePath, table)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(table.extracted) == 0 {
+		return reader, nil
+	}
+
+	return &projectedReader{reader: reader, table: table}, nil
+}
+
+// reads the fields of the headers which aren't extracted
+type projectedReader struct {
+	reader sourceReader
+	table  *Table
+	keep   []int // indexes of the fields read, nil until the headers are read
+}
+
+func (reader *projectedReader) Read() ([]string, error) {
+	row, err := reader.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	if reader.keep == nil {
+		reader.keep = []int{}
+		for idx, header := range row {
+			if !reader.table.isExtractedHeader(header) {
+				reader.keep = append(reader.keep, idx)
+			}
+		}
+	}
+
+	projected := make([]string, len(reader.keep))
+	for idx, position := range reader.keep {
+		projected[idx] = row[position]
+	}
+	return projected, nil
+}
+
+func (reader *projectedReader) Close() error {
+	return reader.reader.Close()
+}
+
+// returns the columns of the tables derived from tableName, directly or not, which it doesn't have
+func (dbSchema *DB) derivedColumns(tableName string) map[string]bool {
+	columns := map[string]bool{}
+
+	for derivedName, table := range dbSchema.Tables {
+		if derivedName == tableName {
+			continue
+		}
+
+		if root, err := dbSchema.derivationRoot(derivedName); err != nil || root != tableName {
+			continue
+		}
+
+		for columnName := range table.Columns {
+			if _, ok := dbSchema.Tables[tableName].Columns[columnName]; !ok {
+				columns[columnName] = true
+			}
+		}
+	}
+
+	return columns
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var enumKinds = []string{"type", "table"}
+
+// max length of Postgres enum labels (NAMEDATALEN - 1)
+const maxEnumLabelLen = 63
+
+// validates the shared enums and normalises their values
+func (dbSchema *DB) validateEnumTypes() error {
+	for enumName, enum := range dbSchema.Enums {
+		if len(enumName) == 0 || enumName != sanitize_db_label(enumName) {
+			return fmt.Errorf("enum name %s isn't sanitized", enumName)
+		}
+
+		if _, ok := dbSchema.Tables[enumName]; ok {
+			return fmt.Errorf("enum name %s conflicts with a table", enumName)
+		}
+
+		enum.Kind = strings.ToLower(strings.TrimSpace(enum.Kind))
+		if enum.Kind == "" {
+			enum.Kind = "type"
+		}
+
+		if !slices.Contains(enumKinds, enum.Kind) {
+			return fmt.Errorf("invalid kind %s for enum %s, should be one of %v", enum.Kind, enumName, enumKinds)
+		}
+
+		enum.DataType = strings.TrimSpace(enum.DataType)
+		if enum.DataType == "" {
+			enum.DataType = "text"
+		}
+
+		if strings.HasSuffix(enum.DataType, "[]") || !isValidTypeName(enum.DataType) {
+			return fmt.Errorf("invalid datatype for enum %s", enumName)
+		}
+
+		if enum.Kind == "type" && enum.DataType != "text" {
+			return fmt.Errorf("enum %s of type kind should be of text datatype", enumName)
+		}
+
+		if len(enum.Values) == 0 {
+			return fmt.Errorf("enum %s has no values", enumName)
+		}
+
+		seen := make(map[string]bool, len(enum.Values))
+		for idx, value := range enum.Values {
+			interfaceVal, ok := validateValueByType(value, enum.DataType)
+			if !ok {
+				return fmt.Errorf("%v value of enum %s is not of %s datatype", value, enumName, enum.DataType)
+			}
+
+			key := fmt.Sprintf("%v", interfaceVal)
+			if seen[key] {
+				return fmt.Errorf("duplicate %v value in enum %s", value, enumName)
+			}
+			seen[key] = true
+
+			if enum.Kind == "type" && len(key) > maxEnumLabelLen {
+				return fmt.Errorf("%v value of enum %s is longer than %d bytes", value, enumName, maxEnumLabelLen)
+			}
+
+			enum.Values[idx] = interfaceVal
+		}
+
+		dbSchema.Enums[enumName] = enum
+	}
+
+	return nil
+}
+
+// resolves the enumRef into column enums, should be called after setMinMaxConstraint
+func (column *Column) setEnumRef(enums map[string]Enum) error {
+	if column.EnumRef == "" {
+		return nil
+	}
+
+	enum, ok := enums[column.EnumRef]
+	if !ok {
+		return fmt.Errorf("enum %s not found", column.EnumRef)
+	}
+
+	if len(column.Enums) > 0 {
+		return errors.New("enums and enumRef can't be used together")
+	}
+
+	isArray := strings.HasSuffix(column.DataType, "[]")
+	if elementType(column.DataType) != enum.DataType {
+		return fmt.Errorf("column should be of %s or %s[] datatype", enum.DataType, enum.DataType)
+	}
+
+	if enum.Kind == "type" && (column.minIndividual != nil || column.maxIndividual != nil) {
+		return errors.New("min/max of individual values can't be used with enum types")
+	}
+
+	if enum.Kind == "table" && isArray {
+		return errors.New("lookup table enums aren't supported for array columns")
+	}
+
+	if enum.Kind == "table" && (column.ForeignTable != "" || column.ForeignField != "") {
+		return errors.New("lookup table enums can't be used with foreign keys")
+	}
+
+	column.Enums = slices.Clone(enum.Values)
+	column.enumKind = enum.Kind
+
+	return nil
+}
+
+// returns the column type used in SQL, enum types are quoted
+func (column Column) SQLType() string {
+	if column.pkStrategy == "bigserial" {
+		return "bigserial"
+	}
+
+	if column.enumKind != "type" {
+		return column.DataType
+	}
+
+	return fmt.Sprintf(`"%s"`, column.EnumRef) + strings.Repeat("[]", arrayDims(column.DataType))
+}
+
+// returns the lookup table referenced by the column, empty if none
+func (column Column) LookupTable() string {
+	if column.enumKind != "table" {
+		return ""
+	}
+
+	return column.EnumRef
+}
+
+// templateValue along with the cast required by enum type arrays
+func templateColumnValue(column Column, value any) string {
+	res := templateValue(value, column.DataType)
+	if res == "NULL" || column.SQLType() == column.DataType || !strings.HasSuffix(column.DataType, "[]") {
+		return res
+	}
+
+	return res + "::" + column.SQLType()
+}
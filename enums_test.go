@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDB_validateEnumTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		enums   map[string]Enum
+		want    Enum
+		wantErr bool
+	}{
+		{
+			name:    "default type kind",
+			enums:   map[string]Enum{"role_type": {Values: []any{"admin", "student"}}},
+			want:    Enum{Kind: "type", DataType: "text", Values: []any{"admin", "student"}},
+			wantErr: false,
+		},
+		{
+			name:    "integer lookup table",
+			enums:   map[string]Enum{"role_type": {Kind: "Table", DataType: "integer", Values: []any{"1", float64(2)}}},
+			want:    Enum{Kind: "table", DataType: "integer", Values: []any{int64(1), int64(2)}},
+			wantErr: false,
+		},
+		{
+			name:    "non text type kind",
+			enums:   map[string]Enum{"role_type": {Kind: "type", DataType: "integer", Values: []any{1}}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate values",
+			enums:   map[string]Enum{"role_type": {Values: []any{"admin", "admin"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unsanitized name",
+			enums:   map[string]Enum{"role type": {Values: []any{"admin"}}},
+			wantErr: true,
+		},
+		{
+			name:    "table name conflict",
+			enums:   map[string]Enum{"login": {Values: []any{"admin"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbSchema := &DB{Enums: tt.enums, Tables: map[string]Table{"login": {}}}
+			err := dbSchema.validateEnumTypes()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.validateEnumTypes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := dbSchema.Enums["role_type"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DB.validateEnumTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumn_setEnumRef(t *testing.T) {
+	enums := map[string]Enum{
+		"role_type": {Kind: "type", DataType: "text", Values: []any{"admin", "student"}},
+		"roles":     {Kind: "table", DataType: "text", Values: []any{"admin", "student"}},
+	}
+
+	tests := []struct {
+		name        string
+		column      Column
+		wantSQLType string
+		wantLookup  string
+		wantErr     bool
+	}{
+		{
+			name:        "enum type array",
+			column:      Column{ColumnName: "role", DataType: "text[]", EnumRef: "role_type"},
+			wantSQLType: `"role_type"[]`,
+			wantErr:     false,
+		},
+		{
+			name:        "lookup table",
+			column:      Column{ColumnName: "role", DataType: "text", EnumRef: "roles"},
+			wantSQLType: "text",
+			wantLookup:  "roles",
+			wantErr:     false,
+		},
+		{
+			name:    "lookup table array",
+			column:  Column{ColumnName: "role", DataType: "text[]", EnumRef: "roles"},
+			wantErr: true,
+		},
+		{
+			name:    "datatype mismatch",
+			column:  Column{ColumnName: "role", DataType: "integer", EnumRef: "role_type"},
+			wantErr: true,
+		},
+		{
+			name:    "enums with enumRef",
+			column:  Column{ColumnName: "role", DataType: "text", EnumRef: "role_type", Enums: []any{"admin"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing enum",
+			column:  Column{ColumnName: "role", DataType: "text", EnumRef: "missing"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.column.setEnumRef(enums)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Column.setEnumRef() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := tt.column.SQLType(); got != tt.wantSQLType {
+				t.Errorf("Column.SQLType() = %v, want %v", got, tt.wantSQLType)
+			}
+			if got := tt.column.LookupTable(); got != tt.wantLookup {
+				t.Errorf("Column.LookupTable() = %v, want %v", got, tt.wantLookup)
+			}
+			if !reflect.DeepEqual(tt.column.Enums, []any{"admin", "student"}) {
+				t.Errorf("Column.setEnumRef() enums = %v", tt.column.Enums)
+			}
+		})
+	}
+}
@@ -2,4 +2,9 @@ module github.com/mainlycricket/CSV_App
 
 go 1.23.0
 
-require golang.org/x/crypto v0.26.0
+require (
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.26.0
+)
+
+require golang.org/x/sys v0.23.0 // indirect
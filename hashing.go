@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var hashAlgorithms = map[string]int{ // algorithm: default cost
+	"bcrypt":   bcrypt.DefaultCost, // log2 of the iterations
+	"argon2id": 2,                  // number of passes over the memory
+}
+
+const (
+	argon2Memory  = 19 * 1024 // KiB
+	argon2Threads = 1
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// validates & sets the default hash algorithm and cost
+func (column *Column) validateHashConfig() error {
+	if !column.Hash {
+		if column.HashAlgorithm != "" || column.HashCost != 0 {
+			return errors.New("hashAlgorithm/hashCost set without enabling hash")
+		}
+		return nil
+	}
+
+	column.HashAlgorithm = strings.ToLower(strings.TrimSpace(column.HashAlgorithm))
+	if column.HashAlgorithm == "" {
+		column.HashAlgorithm = "bcrypt"
+	}
+
+	defaultCost, ok := hashAlgorithms[column.HashAlgorithm]
+	if !ok {
+		return fmt.Errorf("unsupported hash algorithm %s", column.HashAlgorithm)
+	}
+
+	if column.HashCost == 0 {
+		column.HashCost = defaultCost
+	}
+
+	if column.HashAlgorithm == "bcrypt" && (column.HashCost < bcrypt.MinCost || column.HashCost > bcrypt.MaxCost) {
+		return fmt.Errorf("bcrypt cost should be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if column.HashCost < 0 {
+		return errors.New("hash cost should be positive")
+	}
+
+	return nil
+}
+
+func hashPassword(password, algorithm string, cost int) (string, error) {
+	switch algorithm {
+	case "bcrypt":
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashedPassword), nil
+
+	case "argon2id":
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+
+		key := argon2.IDKey([]byte(password), salt, uint32(cost), argon2Memory, argon2Threads, argon2KeyLen)
+
+		encoded := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, cost, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+		return encoded, nil
+	}
+
+	return "", fmt.Errorf("unsupported hash algorithm %s", algorithm)
+}
+
+type hashJob struct {
+	target     *any   // slot holding the text, replaced by its hash
+	location   string // row location used in messages
+	columnName string
+	tableName  string
+	algorithm  string
+	cost       int
+	wg         *sync.WaitGroup
+	err        *error
+	mutex      *sync.Mutex
+}
+
+// bounded pool of goroutines shared by all tables during data insertion
+type hashPool struct {
+	jobs chan hashJob
+}
+
+func newHashPool(workers int) *hashPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	pool := &hashPool{jobs: make(chan hashJob, workers*4)}
+
+	for range workers {
+		go func() {
+			for job := range pool.jobs {
+				job.run()
+			}
+		}()
+	}
+
+	return pool
+}
+
+func (pool *hashPool) close() {
+	close(pool.jobs)
+}
+
+func (job hashJob) run() {
+	defer job.wg.Done()
+
+	str, ok := (*job.target).(string)
+	if !ok {
+		job.setError(errors.New("failed to typecast to text"))
+		return
+	}
+
+	hashedVal, err := hashPassword(str, job.algorithm, job.cost)
+	if err != nil {
+		job.setError(fmt.Errorf("error while hashing %s: %v", str, err))
+		return
+	}
+
+	*job.target = hashedVal
+}
+
+func (job hashJob) setError(err error) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	if *job.err == nil {
+		*job.err = fmt.Errorf("error in %s in %s column of %s table: %v", job.location, job.columnName, job.tableName, err)
+	}
+}
+
+// hashes the text, text[] values of hash columns in the provided rows concurrently
+// rows contain the validated values ordered as per headers, rowIdxs are their row no.
+func (pool *hashPool) hashRows(rows [][]any, rowIdxs []int, headers []string, table *Table) error {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var mainError error
+
+	for rowNum, row := range rows {
+		for idx, val := range row {
+			column := table.Columns[headers[idx]]
+
+			if !column.Hash || val == nil {
+				continue
+			}
+
+			job := hashJob{
+				location:   table.rowLocation(rowIdxs[rowNum]),
+				columnName: column.ColumnName,
+				tableName:  table.TableName,
+				algorithm:  column.HashAlgorithm,
+				cost:       column.HashCost,
+				wg:         &wg,
+				err:        &mainError,
+				mutex:      &mutex,
+			}
+
+			if column.DataType == "text" {
+				job.target = &row[idx]
+				wg.Add(1)
+				pool.jobs <- job
+				continue
+			}
+
+			arr, ok := val.([]any)
+			if !ok {
+				wg.Wait()
+				return fmt.Errorf("error in %s in %s column of %s table: failed to typecast to text[]", job.location, job.columnName, job.tableName)
+			}
+
+			for itemIdx := range arr {
+				job.target = &arr[itemIdx]
+				wg.Add(1)
+				pool.jobs <- job
+			}
+		}
+	}
+
+	wg.Wait()
+	return mainError
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestColumn_validateHashConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   Column
+		wantAlgo string
+		wantCost int
+		wantErr  bool
+	}{
+		{
+			name:     "default bcrypt",
+			column:   Column{Hash: true},
+			wantAlgo: "bcrypt",
+			wantCost: 10,
+			wantErr:  false,
+		},
+		{
+			name:     "argon2id default cost",
+			column:   Column{Hash: true, HashAlgorithm: " Argon2id "},
+			wantAlgo: "argon2id",
+			wantCost: 2,
+			wantErr:  false,
+		},
+		{
+			name:    "invalid bcrypt cost",
+			column:  Column{Hash: true, HashCost: 40},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported algorithm",
+			column:  Column{Hash: true, HashAlgorithm: "md5"},
+			wantErr: true,
+		},
+		{
+			name:    "algorithm without hash",
+			column:  Column{HashAlgorithm: "bcrypt"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.column.validateHashConfig()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Column.validateHashConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.column.HashAlgorithm != tt.wantAlgo || tt.column.HashCost != tt.wantCost {
+				t.Errorf("Column.validateHashConfig() = %v %v, want %v %v", tt.column.HashAlgorithm, tt.column.HashCost, tt.wantAlgo, tt.wantCost)
+			}
+		})
+	}
+}
+
+// hashes are verified by comparePassword of the app template, which the generated app logs in with
+func Test_hashPassword(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program with comparePassword of templates/utils.tmpl")
+	}
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	utils, err := os.ReadFile(filepath.Join("templates", "utils.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := bytes.Index(utils, []byte("func comparePassword("))
+	end := bytes.Index(utils[max(start, 0):], []byte("\n}\n"))
+	if start == -1 || end == -1 {
+		t.Fatal("comparePassword not found in templates/utils.tmpl")
+	}
+
+	program := `package main
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func main() {
+	for _, hashed := range os.Args[1:] {
+		if err := comparePassword("secret", hashed); err != nil {
+			fmt.Printf("%s: %v\n", hashed, err)
+			os.Exit(1)
+		}
+		if err := comparePassword("wrong", hashed); err == nil {
+			fmt.Printf("%s: wrong password accepted\n", hashed)
+			os.Exit(1)
+		}
+	}
+}
+
+` + string(utils[start:start+end+3])
+
+	dir := t.TempDir()
+	files := map[string][]byte{"main.go": []byte(program)}
+	for _, fileName := range []string{"go.mod", "go.sum"} {
+		if files[fileName], err = os.ReadFile(fileName); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for fileName, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, fileName), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	args := []string{"run", "."}
+	for _, algorithm := range []string{"bcrypt", "argon2id"} {
+		hashed, err := hashPassword("secret", algorithm, map[string]int{"bcrypt": 4, "argon2id": 1}[algorithm])
+		if err != nil {
+			t.Fatalf("hashPassword() error = %v", err)
+		}
+		args = append(args, hashed)
+	}
+
+	cmd := exec.Command(goBin, args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("comparePassword() of app template error = %v\n%s", err, output)
+	}
+}
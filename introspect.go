@@ -0,0 +1,673 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// postgres types read back as schema.json datatypes, the ones with a note lose precision or behaviour
+var postgresTypes = map[string]struct {
+	dataType string
+	note     string
+}{
+	"smallint":                    {dataType: "smallint"},
+	"integer":                     {dataType: "integer"},
+	"bigint":                      {dataType: "bigint"},
+	"real":                        {dataType: "real"},
+	"double precision":            {dataType: "real", note: "double precision read as real"},
+	"numeric":                     {dataType: "numeric"},
+	"text":                        {dataType: "text"},
+	"character varying":           {dataType: "text"},
+	"character":                   {dataType: "text"},
+	"uuid":                        {dataType: "uuid"},
+	"jsonb":                       {dataType: "jsonb"},
+	"json":                        {dataType: "jsonb", note: "json read as jsonb"},
+	"boolean":                     {dataType: "boolean"},
+	"date":                        {dataType: "date"},
+	"time without time zone":      {dataType: "time"},
+	"time with time zone":         {dataType: "time", note: "time with time zone read as time"},
+	"timestamp with time zone":    {dataType: "timestamptz"},
+	"timestamp without time zone": {dataType: "timestamptz", note: "timestamp without time zone read as timestamptz"},
+}
+
+// pg_constraint confupdtype & confdeltype codes as referential action names, blank for other constraints
+var foreignKeyActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+var (
+	typeModifierRegex = regexp.MustCompile(`\((\d+)(?:,(\d+))?\)`)
+	castRegex         = regexp.MustCompile(`::(?:character varying|double precision|(?:timestamp|time)(?:\(\d+\))? with(?:out)? time zone|"[^"]+"|\w+)(?:\(\d+(?:,\d+)?\))?(?:\[\])*`)
+	nextvalRegex      = regexp.MustCompile(`(?i)^nextval\('(?:[^'.]+\.)?"?([a-zA-Z0-9_]+)"?'\)$`)
+	boundRegex        = regexp.MustCompile(`^(?:(?:length|char_length)\(\(?"?(\w+)"?\)?\)|\(?"?(\w+)"?\)?) (>=|>|<=|<) (.+)$`)
+	anyRegex          = regexp.MustCompile(`^\(?"?(\w+)"?\)? = ANY \(\(?ARRAY\[(.*)\]\)?\)$`)
+	matchRegex        = regexp.MustCompile(`^\(?"?(\w+)"?\)? ~ '(.*)'$`)
+	collateRegex      = regexp.MustCompile(`\(('(?:[^']|'')*'|"?\w+"?) COLLATE "C"\)|('(?:[^']|'')*'|"?\w+"?) COLLATE "C"`)
+)
+
+// tables, columns & constraints of a postgres schema, as read from information_schema & pg_catalog
+type introspectedCatalog struct {
+	tables      map[string]string   // key: table name, value: description
+	enums       map[string][]string // key: enum type, value: labels in sort order
+	columns     []introspectedColumn
+	constraints []introspectedConstraint
+}
+
+type introspectedColumn struct {
+	tableName   string
+	columnName  string
+	dataType    string // as formatted by format_type, e.g. character varying(50)[]
+	notNull     bool
+	defaultExpr string // as stored, e.g. nextval('students_roll_seq'::regclass)
+	identity    bool
+	description string
+}
+
+type introspectedConstraint struct {
+	tableName      string
+	name           string
+	kind           string // p (primary key), u (unique), f (foreign key) or c (check)
+	columns        []string
+	foreignTable   string
+	foreignColumns []string
+	onUpdate       string
+	onDelete       string
+	definition     string // as returned by pg_get_constraintdef
+}
+
+// reads the schema of an existing database into schema.json & the default appConfig.json
+// the tables have no sources, the report lists what couldn't be mapped
+func generateIntrospection(options *IntrospectOptions) ([]string, error) {
+	if options.Database == "" {
+		return nil, errors.New("database connection string is required to introspect")
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	dataPath := filepath.Join(basePath, "data")
+	if err := os.MkdirAll(dataPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("postgres", options.Database)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	catalog, err := readCatalog(db, options.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading %s schema: %v", options.Schema, err)
+	}
+
+	if len(catalog.tables) == 0 {
+		return nil, fmt.Errorf("no tables found in %s schema", options.Schema)
+	}
+
+	dbSchema, report := catalog.buildSchema()
+	dbSchema.BasePath = dataPath
+
+	schemaFilePath := filepath.Join(dataPath, "schema.json")
+	if err := writeJsonFile(schemaFilePath, dbSchema); err != nil {
+		return report, err
+	}
+
+	appConfig := AppCongif{
+		SchemaPath: schemaFilePath,
+		Tables:     make(map[string]TableConfig, len(dbSchema.Tables)),
+	}
+	appConfig.setTables(&dbSchema)
+
+	if err := writeJsonFile(filepath.Join(dataPath, "appConfig.json"), appConfig); err != nil {
+		return report, fmt.Errorf("failed to write appConfig.json: %v", err)
+	}
+
+	// validation converts the values, hence a copy as written is validated
+	var written DB
+	if err := readJsonFile(schemaFilePath, &written); err != nil {
+		return report, err
+	}
+
+	if err := written.validateSchema(); err != nil {
+		return report, fmt.Errorf("schema.json needs manual edits: %v", err)
+	}
+
+	return report, nil
+}
+
+func readCatalog(db *sql.DB, schema string) (introspectedCatalog, error) {
+	catalog := introspectedCatalog{tables: map[string]string{}, enums: map[string][]string{}}
+
+	tableRows, err := db.Query(`SELECT c.relname, COALESCE(obj_description(c.oid, 'pg_class'), '')
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND NOT c.relispartition`, schema)
+	if err != nil {
+		return catalog, err
+	}
+	defer tableRows.Close()
+
+	for tableRows.Next() {
+		var tableName, description string
+		if err := tableRows.Scan(&tableName, &description); err != nil {
+			return catalog, err
+		}
+		catalog.tables[tableName] = description
+	}
+	if err := tableRows.Err(); err != nil {
+		return catalog, err
+	}
+
+	enumRows, err := db.Query(`SELECT t.typname, e.enumlabel
+FROM pg_catalog.pg_enum e
+JOIN pg_catalog.pg_type t ON t.oid = e.enumtypid
+JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace
+WHERE n.nspname = $1
+ORDER BY t.typname, e.enumsortorder`, schema)
+	if err != nil {
+		return catalog, err
+	}
+	defer enumRows.Close()
+
+	for enumRows.Next() {
+		var enumName, label string
+		if err := enumRows.Scan(&enumName, &label); err != nil {
+			return catalog, err
+		}
+		catalog.enums[enumName] = append(catalog.enums[enumName], label)
+	}
+	if err := enumRows.Err(); err != nil {
+		return catalog, err
+	}
+
+	columnRows, err := db.Query(`SELECT c.table_name, c.column_name, format_type(a.atttypid, a.atttypmod), c.is_nullable = 'NO',
+	COALESCE(c.column_default, ''), c.is_identity = 'YES', COALESCE(col_description(a.attrelid, a.attnum), '')
+FROM information_schema.columns c
+JOIN pg_catalog.pg_namespace n ON n.nspname = c.table_schema
+JOIN pg_catalog.pg_class cl ON cl.relnamespace = n.oid AND cl.relname = c.table_name
+JOIN pg_catalog.pg_attribute a ON a.attrelid = cl.oid AND a.attname = c.column_name
+WHERE c.table_schema = $1
+ORDER BY c.table_name, c.ordinal_position`, schema)
+	if err != nil {
+		return catalog, err
+	}
+	defer columnRows.Close()
+
+	for columnRows.Next() {
+		var column introspectedColumn
+		if err := columnRows.Scan(&column.tableName, &column.columnName, &column.dataType, &column.notNull, &column.defaultExpr, &column.identity, &column.description); err != nil {
+			return catalog, err
+		}
+		if _, ok := catalog.tables[column.tableName]; ok {
+			catalog.columns = append(catalog.columns, column)
+		}
+	}
+	if err := columnRows.Err(); err != nil {
+		return catalog, err
+	}
+
+	constraintRows, err := db.Query(`SELECT cl.relname, con.conname, con.contype::text,
+	ARRAY(SELECT a.attname::text FROM unnest(con.conkey) WITH ORDINALITY k(attnum, idx)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum ORDER BY k.idx),
+	COALESCE(fcl.relname, ''),
+	ARRAY(SELECT a.attname::text FROM unnest(con.confkey) WITH ORDINALITY k(attnum, idx)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum ORDER BY k.idx),
+	con.confupdtype::text, con.confdeltype::text, pg_get_constraintdef(con.oid)
+FROM pg_catalog.pg_constraint con
+JOIN pg_catalog.pg_class cl ON cl.oid = con.conrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = cl.relnamespace
+LEFT JOIN pg_catalog.pg_class fcl ON fcl.oid = con.confrelid
+WHERE n.nspname = $1 AND con.contype IN ('p', 'u', 'f', 'c')`, schema)
+	if err != nil {
+		return catalog, err
+	}
+	defer constraintRows.Close()
+
+	for constraintRows.Next() {
+		var constraint introspectedConstraint
+		if err := constraintRows.Scan(&constraint.tableName, &constraint.name, &constraint.kind, pq.Array(&constraint.columns), &constraint.foreignTable,
+			pq.Array(&constraint.foreignColumns), &constraint.onUpdate, &constraint.onDelete, &constraint.definition); err != nil {
+			return catalog, err
+		}
+		constraint.onUpdate, constraint.onDelete = foreignKeyActions[constraint.onUpdate], foreignKeyActions[constraint.onDelete]
+		if _, ok := catalog.tables[constraint.tableName]; ok {
+			catalog.constraints = append(catalog.constraints, constraint)
+		}
+	}
+
+	return catalog, constraintRows.Err()
+}
+
+// maps the catalog to schema.json, the report lists the types, defaults & constraints which couldn't be mapped
+func (catalog *introspectedCatalog) buildSchema() (DB, []string) {
+	dbSchema := DB{
+		Timezone: "UTC",
+		Parsing:  defaultParseOptions(),
+		Naming:   NamingOptions{ReservedWords: "keep", MaxLength: maxIdentifierLen}, // names are kept as is
+		Enums:    map[string]Enum{},
+		Tables:   make(map[string]Table, len(catalog.tables)),
+	}
+	report := []string{}
+
+	for _, enumName := range slices.Sorted(maps.Keys(catalog.enums)) {
+		values := make([]interface{}, len(catalog.enums[enumName]))
+		for idx, label := range catalog.enums[enumName] {
+			values[idx] = label
+		}
+		dbSchema.Enums[enumName] = Enum{Kind: "type", DataType: "text", Values: values}
+	}
+
+	for tableName, description := range catalog.tables {
+		dbSchema.Tables[tableName] = Table{TableName: tableName, Description: description, Columns: map[string]Column{}, Checks: map[string]string{}}
+	}
+
+	for _, introspected := range catalog.columns {
+		column, note := mapPostgresType(introspected.dataType, dbSchema.Enums)
+		if note != "" {
+			report = append(report, fmt.Sprintf("%s.%s: %s", introspected.tableName, introspected.columnName, note))
+		}
+
+		column.ColumnName = introspected.columnName
+		column.Description = introspected.description
+		column.NotNull = introspected.notNull
+		dbSchema.Tables[introspected.tableName].Columns[introspected.columnName] = column
+	}
+
+	// primary keys first, defaults & foreign keys depend on them
+	constraints := slices.Clone(catalog.constraints)
+	slices.SortFunc(constraints, func(a, b introspectedConstraint) int {
+		return cmp.Or(cmp.Compare(constraintRank(a.kind), constraintRank(b.kind)), strings.Compare(a.tableName, b.tableName), strings.Compare(a.kind, b.kind), strings.Compare(a.name, b.name))
+	})
+
+	for _, constraint := range constraints {
+		table := dbSchema.Tables[constraint.tableName]
+
+		switch constraint.kind {
+		case "p":
+			if len(constraint.columns) != 1 {
+				report = append(report, fmt.Sprintf("%s: composite primary key (%s) not mapped, app requires a single column key", constraint.tableName, strings.Join(constraint.columns, ", ")))
+				continue
+			}
+			table.PrimaryKey = constraint.columns[0]
+
+		case "u":
+			if len(constraint.columns) != 1 {
+				report = append(report, fmt.Sprintf("%s: unique (%s) not mapped", constraint.tableName, strings.Join(constraint.columns, ", ")))
+				continue
+			}
+			if constraint.columns[0] != table.PrimaryKey {
+				column := table.Columns[constraint.columns[0]]
+				column.Unique = true
+				table.Columns[constraint.columns[0]] = column
+			}
+
+		case "f":
+			if !dbSchema.setIntrospectedForeignKey(constraint) {
+				report = append(report, fmt.Sprintf("%s: foreign key %s not mapped: %s", constraint.tableName, constraint.name, constraint.definition))
+			}
+
+		case "c":
+			if !table.setIntrospectedCheck(constraint.name, constraint.definition, constraint.columns) {
+				report = append(report, fmt.Sprintf("%s: check %s not mapped: %s", constraint.tableName, constraint.name, constraint.definition))
+			}
+		}
+
+		dbSchema.Tables[constraint.tableName] = table
+	}
+
+	for _, introspected := range catalog.columns {
+		table := dbSchema.Tables[introspected.tableName]
+
+		if introspected.identity {
+			if !table.setIdentityKey(introspected.columnName) {
+				report = append(report, fmt.Sprintf("%s.%s: identity not mapped, only integer primary keys can be identity", introspected.tableName, introspected.columnName))
+			}
+		} else if introspected.defaultExpr != "" && !table.setIntrospectedDefault(introspected.columnName, introspected.defaultExpr) {
+			report = append(report, fmt.Sprintf("%s.%s: default not mapped: %s", introspected.tableName, introspected.columnName, introspected.defaultExpr))
+		}
+
+		dbSchema.Tables[introspected.tableName] = table
+	}
+
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		if dbSchema.Tables[tableName].PrimaryKey == "" {
+			report = append(report, fmt.Sprintf("%s: no primary key, app requires one", tableName))
+		}
+	}
+
+	return dbSchema, report
+}
+
+// 0 for primary keys, 1 for other constraints
+func constraintRank(kind string) int {
+	if kind == "p" {
+		return 0
+	}
+	return 1
+}
+
+// maps a type formatted by format_type to a column with its datatype
+// varchar & char lengths are read as max, enum types as enumRef, unsupported types as text
+func mapPostgresType(formatted string, enums map[string]Enum) (Column, string) {
+	dims := strings.Count(formatted, "[]")
+	name := strings.TrimSpace(strings.ReplaceAll(formatted, "[]", ""))
+	arraySuffix := strings.Repeat("[]", dims)
+
+	enumName := name
+	if idx := strings.LastIndex(enumName, "."); idx != -1 {
+		enumName = enumName[idx+1:]
+	}
+	if enumName = strings.Trim(enumName, `"`); enums[enumName].Kind == "type" {
+		return Column{DataType: "text" + arraySuffix, EnumRef: enumName}, ""
+	}
+
+	modifiers := typeModifierRegex.FindStringSubmatch(name)
+	name = strings.Join(strings.Fields(typeModifierRegex.ReplaceAllString(name, "")), " ")
+
+	postgresType, ok := postgresTypes[name]
+	if !ok {
+		return Column{DataType: "text" + arraySuffix}, fmt.Sprintf("unsupported type %s read as text", formatted)
+	}
+
+	column := Column{DataType: postgresType.dataType}
+	switch {
+	case modifiers != nil && name == "numeric":
+		column.DataType = "numeric" + modifiers[0]
+	case modifiers != nil && (name == "character varying" || name == "character"):
+		column.Max = modifiers[1]
+		if dims > 0 {
+			column.Max = "," + column.Max
+		}
+	}
+
+	column.DataType += arraySuffix
+	return column, postgresType.note
+}
+
+// sets the foreign key of a single column referencing the primary key of another table
+func (dbSchema *DB) setIntrospectedForeignKey(constraint introspectedConstraint) bool {
+	if len(constraint.columns) != 1 || len(constraint.foreignColumns) != 1 {
+		return false
+	}
+
+	referencedTable, ok := dbSchema.Tables[constraint.foreignTable]
+	if !ok || referencedTable.PrimaryKey != constraint.foreignColumns[0] {
+		return false
+	}
+
+	table := dbSchema.Tables[constraint.tableName]
+	column := table.Columns[constraint.columns[0]]
+	if column.DataType != referencedTable.Columns[referencedTable.PrimaryKey].DataType {
+		return false
+	}
+
+	column.ForeignTable, column.ForeignField = constraint.foreignTable, constraint.foreignColumns[0]
+	column.OnUpdate, column.OnDelete = constraint.onUpdate, constraint.onDelete
+	table.Columns[column.ColumnName] = column
+
+	return true
+}
+
+// maps a check over a single column to its min, max, enums & pattern
+// other checks are kept as table checks if they're supported by the check grammar
+func (table *Table) setIntrospectedCheck(name, definition string, columns []string) bool {
+	expression := strings.TrimSuffix(strings.TrimPrefix(definition, "CHECK "), " NOT VALID")
+	expression = collateRegex.ReplaceAllString(stripCasts(expression), "$1$2")
+
+	if len(columns) == 1 {
+		column := table.Columns[columns[0]]
+		if column.setIntrospectedBounds(expression) {
+			table.Columns[columns[0]] = column
+			return true
+		}
+	}
+
+	check := Table{Columns: table.Columns, Checks: map[string]string{name: expression}}
+	if err := check.setChecks(); err != nil {
+		return false
+	}
+
+	table.Checks[name] = expression
+	return true
+}
+
+// sets the constraints of the conjuncts of a check expression without casts, false if any isn't mapped
+func (column *Column) setIntrospectedBounds(expression string) bool {
+	if arrayDims(column.DataType) > 0 {
+		return false
+	}
+
+	updated := *column
+	for _, conjunct := range splitSQL(unwrapParens(expression), " AND ") {
+		conjunct = unwrapParens(conjunct)
+
+		if matches := boundRegex.FindStringSubmatch(conjunct); matches != nil {
+			isLength := matches[1] != ""
+			if target := matches[1] + matches[2]; target != column.ColumnName || isLength != (column.DataType == "text") {
+				return false
+			}
+
+			value := sqlLiteral(matches[4])
+			switch matches[3] {
+			case ">=", ">":
+				updated.Min, updated.MinExclusive = value, matches[3] == ">"
+			default:
+				updated.Max, updated.MaxExclusive = value, matches[3] == "<"
+			}
+			continue
+		}
+
+		if matches := anyRegex.FindStringSubmatch(conjunct); matches != nil && matches[1] == column.ColumnName && column.EnumRef == "" {
+			updated.Enums = nil
+			for _, value := range splitSQL(matches[2], ", ") {
+				enumValue, ok := introspectedValue(sqlLiteral(value), column.DataType)
+				if !ok {
+					return false
+				}
+				updated.Enums = append(updated.Enums, enumValue)
+			}
+			continue
+		}
+
+		if matches := matchRegex.FindStringSubmatch(conjunct); matches != nil && matches[1] == column.ColumnName && updated.Pattern == "" && column.DataType == "text" {
+			updated.Pattern = strings.ReplaceAll(matches[2], "''", "'")
+			continue
+		}
+
+		return false
+	}
+
+	validated := updated
+	if validated.setMinMaxConstraint() != nil || validated.validateEnums() != nil || validated.setPatternConstraint() != nil {
+		return false
+	}
+
+	*column = updated
+	return true
+}
+
+// sets the default of a column, nextval, now & the like as defaultExpr
+// uuid defaults of primary keys are read as their strategy
+func (table *Table) setIntrospectedDefault(columnName, expr string) bool {
+	column := table.Columns[columnName]
+	isPrimaryKey := columnName == table.PrimaryKey
+	expr = strings.TrimSpace(expr)
+
+	stripped := unwrapParens(stripCasts(expr))
+
+	// the sequence keeps its case, it's quoted by the nextval default
+	switch function := strings.ToLower(stripped); {
+	case nextvalRegex.MatchString(stripped):
+		column.DefaultExpr = fmt.Sprintf("nextval('%s')", nextvalRegex.FindStringSubmatch(stripped)[1])
+	case function == "now()" || function == "current_timestamp":
+		column.DefaultExpr = "now()"
+	case function == "current_date":
+		column.DefaultExpr = "current_date"
+	case function == "current_time" || function == "localtime":
+		column.DefaultExpr = "current_time"
+	case function == "gen_random_uuid()" && isPrimaryKey:
+		return table.setUUIDKey("uuid_v4")
+	case function == "uuid_generate_v7()" && isPrimaryKey:
+		return table.setUUIDKey("uuid_v7")
+	case function == "gen_random_uuid()":
+		column.DefaultExpr = "gen_random_uuid()"
+	case function == "null":
+		return true
+	default:
+		value, ok := introspectedValue(sqlLiteral(stripped), column.DataType)
+		if !ok || arrayDims(column.DataType) > 0 {
+			return false
+		}
+		column.Default = value
+	}
+
+	validated := column
+	if validated.validateDefaultValue() != nil || validated.setDefaultExpr() != nil {
+		return false
+	}
+
+	table.Columns[columnName] = column
+	return true
+}
+
+// sets the identity strategy of an integer primary key
+func (table *Table) setIdentityKey(columnName string) bool {
+	if columnName != table.PrimaryKey || !isIntegerType(table.Columns[columnName].DataType) {
+		return false
+	}
+
+	table.PrimaryKeyStrategy = "identity"
+	return true
+}
+
+// sets the uuid strategy of a text or uuid primary key
+func (table *Table) setUUIDKey(strategy string) bool {
+	if dataType := table.Columns[table.PrimaryKey].DataType; dataType != "text" && dataType != "uuid" {
+		return false
+	}
+
+	table.PrimaryKeyStrategy = strategy
+	return true
+}
+
+// typed value of a SQL literal, numbers & booleans are kept typed while others stay as written
+func introspectedValue(literal, datatype string) (any, bool) {
+	value, ok := validateValueByType(literal, datatype)
+	if !ok || value == nil {
+		return nil, false
+	}
+
+	switch value.(type) {
+	case int64, float64, bool:
+		return value, true
+	}
+	return literal, true
+}
+
+// returns the value of a SQL literal, e.g. 'O”Brien' -> O'Brien, (-1) -> -1
+func sqlLiteral(literal string) string {
+	literal = unwrapParens(literal)
+	if len(literal) >= 2 && strings.HasPrefix(literal, "'") && strings.HasSuffix(literal, "'") {
+		return strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	}
+	return literal
+}
+
+// removes casts outside of string literals, e.g. ('a::b'::text) -> ('a::b')
+func stripCasts(expression string) string {
+	var stripped strings.Builder
+	start, quoted := 0, false
+
+	for idx := 0; idx < len(expression); idx++ {
+		if expression[idx] != '\'' {
+			continue
+		}
+
+		if quoted {
+			stripped.WriteString(expression[start : idx+1])
+			start = idx + 1
+		} else {
+			stripped.WriteString(castRegex.ReplaceAllString(expression[start:idx], ""))
+			start = idx
+		}
+		quoted = !quoted
+	}
+
+	if quoted {
+		return stripped.String() + expression[start:]
+	}
+	return stripped.String() + castRegex.ReplaceAllString(expression[start:], "")
+}
+
+// removes the parentheses enclosing the whole expression
+func unwrapParens(expression string) string {
+	expression = strings.TrimSpace(expression)
+	for strings.HasPrefix(expression, "(") && strings.HasSuffix(expression, ")") {
+		if closesEarly(expression) {
+			return expression
+		}
+		expression = strings.TrimSpace(expression[1 : len(expression)-1])
+	}
+	return expression
+}
+
+// true if the opening parenthesis of expression closes before its end, e.g. (a) AND (b)
+func closesEarly(expression string) bool {
+	depth, quoted := 0, false
+	for idx, char := range expression {
+		switch {
+		case char == '\'':
+			quoted = !quoted
+		case quoted:
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+			if depth == 0 && idx != len(expression)-1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splits expression by separator outside of parentheses, brackets & quotes
+func splitSQL(expression, separator string) []string {
+	parts := []string{}
+	depth, quoted, start := 0, false, 0
+
+	for idx := 0; idx < len(expression); idx++ {
+		switch char := expression[idx]; {
+		case char == '\'':
+			quoted = !quoted
+		case quoted:
+		case char == '(' || char == '[':
+			depth++
+		case char == ')' || char == ']':
+			depth--
+		case depth == 0 && strings.HasPrefix(expression[idx:], separator):
+			parts = append(parts, expression[start:idx])
+			start = idx + len(separator)
+			idx += len(separator) - 1
+		}
+	}
+
+	return append(parts, expression[start:])
+}
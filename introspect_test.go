@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func Test_mapPostgresType(t *testing.T) {
+	enums := map[string]Enum{"status": {Kind: "type", DataType: "text"}}
+	tests := []struct {
+		formatted string
+		want      Column
+		note      bool
+	}{
+		{formatted: "integer", want: Column{DataType: "integer"}},
+		{formatted: "numeric(10,2)", want: Column{DataType: "numeric(10,2)"}},
+		{formatted: "character varying(50)", want: Column{DataType: "text", Max: "50"}},
+		{formatted: "character varying(20)[]", want: Column{DataType: "text[]", Max: ",20"}},
+		{formatted: "timestamp(3) with time zone", want: Column{DataType: "timestamptz"}},
+		{formatted: "timestamp without time zone", want: Column{DataType: "timestamptz"}, note: true},
+		{formatted: "status[]", want: Column{DataType: "text[]", EnumRef: "status"}},
+		{formatted: `public."status"`, want: Column{DataType: "text", EnumRef: "status"}},
+		{formatted: "bytea", want: Column{DataType: "text"}, note: true},
+		{formatted: "double precision[]", want: Column{DataType: "real[]"}, note: true},
+	}
+	for _, tt := range tests {
+		got, note := mapPostgresType(tt.formatted, enums)
+		if !reflect.DeepEqual(got, tt.want) || (note != "") != tt.note {
+			t.Errorf("mapPostgresType(%s) = %+v, %q, want %+v", tt.formatted, got, note, tt.want)
+		}
+	}
+}
+
+func Test_foreignKeyActions(t *testing.T) {
+	tests := map[string]string{"a": "NO ACTION", "r": "RESTRICT", "c": "CASCADE", "n": "SET NULL", "d": "SET DEFAULT", "": ""}
+	for code, want := range tests {
+		if got := foreignKeyActions[code]; got != want {
+			t.Errorf("foreignKeyActions[%q] = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func Test_setIntrospectedCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		column     Column
+		want       Column
+		check      string
+	}{
+		{
+			name:       "text length",
+			definition: "CHECK (((length(name) >= 3) AND (length(name) < 50)))",
+			column:     Column{ColumnName: "name", DataType: "text"},
+			want:       Column{ColumnName: "name", DataType: "text", Min: "3", Max: "50", MaxExclusive: true},
+		},
+		{
+			name:       "numeric bound",
+			definition: "CHECK ((fee > (0)::numeric))",
+			column:     Column{ColumnName: "fee", DataType: "numeric(10,2)"},
+			want:       Column{ColumnName: "fee", DataType: "numeric(10,2)", Min: "0", MinExclusive: true},
+		},
+		{
+			name:       "date bound",
+			definition: "CHECK ((dob >= '2000-01-01'::date))",
+			column:     Column{ColumnName: "dob", DataType: "date"},
+			want:       Column{ColumnName: "dob", DataType: "date", Min: "2000-01-01"},
+		},
+		{
+			name:       "varchar enums",
+			definition: "CHECK (((code)::text = ANY ((ARRAY['x'::character varying, 'O''k'::character varying])::text[])))",
+			column:     Column{ColumnName: "code", DataType: "text"},
+			want:       Column{ColumnName: "code", DataType: "text", Enums: []interface{}{"x", "O'k"}},
+		},
+		{
+			name:       "integer enums",
+			definition: "CHECK ((semester = ANY (ARRAY[1, 2])))",
+			column:     Column{ColumnName: "semester", DataType: "integer"},
+			want:       Column{ColumnName: "semester", DataType: "integer", Enums: []interface{}{int64(1), int64(2)}},
+		},
+		{
+			name:       "pattern",
+			definition: `CHECK ((email ~ '^[a-z]+@x\.com$'::text))`,
+			column:     Column{ColumnName: "email", DataType: "text"},
+			want:       Column{ColumnName: "email", DataType: "text", Pattern: `^[a-z]+@x\.com$`},
+		},
+		{
+			name:       "pattern with colons",
+			definition: `CHECK ((code ~ '^[a-z]+::int$'::text))`,
+			column:     Column{ColumnName: "code", DataType: "text"},
+			want:       Column{ColumnName: "code", DataType: "text", Pattern: `^[a-z]+::int$`},
+		},
+		{
+			name:       "text order",
+			definition: `CHECK ((code >= ('A'::text COLLATE "C")))`,
+			column:     Column{ColumnName: "code", DataType: "text"},
+			want:       Column{ColumnName: "code", DataType: "text"},
+			check:      "((code >= 'A'))",
+		},
+		{
+			name:       "table check",
+			definition: "CHECK ((email IS NOT NULL))",
+			column:     Column{ColumnName: "email", DataType: "text"},
+			want:       Column{ColumnName: "email", DataType: "text"},
+			check:      "((email IS NOT NULL))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := Table{Columns: map[string]Column{tt.column.ColumnName: tt.column}, Checks: map[string]string{}}
+			if !table.setIntrospectedCheck("check", tt.definition, []string{tt.column.ColumnName}) {
+				t.Fatalf("setIntrospectedCheck() not mapped")
+			}
+			if got := table.Columns[tt.column.ColumnName]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("setIntrospectedCheck() column = %+v, want %+v", got, tt.want)
+			}
+			if got := table.Checks["check"]; got != tt.check {
+				t.Errorf("setIntrospectedCheck() check = %q, want %q", got, tt.check)
+			}
+		})
+	}
+
+	table := Table{Columns: map[string]Column{"name": {ColumnName: "name", DataType: "text"}}, Checks: map[string]string{}}
+	if table.setIntrospectedCheck("check", "CHECK ((upper(name) <> name))", []string{"name"}) {
+		t.Errorf("setIntrospectedCheck() mapped an unsupported check")
+	}
+}
+
+func Test_setIntrospectedDefault(t *testing.T) {
+	tests := []struct {
+		expr     string
+		dataType string
+		want     Column
+		strategy string
+		ok       bool
+	}{
+		{expr: "nextval('students_roll_seq'::regclass)", dataType: "integer", want: Column{DefaultExpr: "nextval('students_roll_seq')"}, ok: true},
+		{expr: `nextval('public."Students_Roll_seq"'::regclass)`, dataType: "integer", want: Column{DefaultExpr: "nextval('Students_Roll_seq')"}, ok: true},
+		{expr: "CURRENT_TIMESTAMP", dataType: "timestamptz", want: Column{DefaultExpr: "now()"}, ok: true},
+		{expr: "(gen_random_uuid())::text", dataType: "text", want: Column{DefaultExpr: "gen_random_uuid()"}, ok: true},
+		{expr: "'O''Brien'::character varying", dataType: "text", want: Column{Default: "O'Brien"}, ok: true},
+		{expr: "'a::b'::text", dataType: "text", want: Column{Default: "a::b"}, ok: true},
+		{expr: "'-1'::integer", dataType: "integer", want: Column{Default: int64(-1)}, ok: true},
+		{expr: "true", dataType: "boolean", want: Column{Default: true}, ok: true},
+		{expr: "now()", dataType: "date", ok: false},
+		{expr: "'{1,2}'::integer[]", dataType: "integer[]", ok: false},
+	}
+	for _, tt := range tests {
+		table := Table{Columns: map[string]Column{"col": {ColumnName: "col", DataType: tt.dataType}}}
+		ok := table.setIntrospectedDefault("col", tt.expr)
+		if ok != tt.ok {
+			t.Errorf("setIntrospectedDefault(%s) = %v, want %v", tt.expr, ok, tt.ok)
+			continue
+		}
+
+		got := table.Columns["col"]
+		if ok && (got.Default != tt.want.Default || got.DefaultExpr != tt.want.DefaultExpr) {
+			t.Errorf("setIntrospectedDefault(%s) = %v, %q, want %v, %q", tt.expr, got.Default, got.DefaultExpr, tt.want.Default, tt.want.DefaultExpr)
+		}
+	}
+
+	table := Table{PrimaryKey: "id", Columns: map[string]Column{"id": {ColumnName: "id", DataType: "uuid"}}}
+	if !table.setIntrospectedDefault("id", "gen_random_uuid()") || table.PrimaryKeyStrategy != "uuid_v4" {
+		t.Errorf("setIntrospectedDefault() primary key strategy = %q, want uuid_v4", table.PrimaryKeyStrategy)
+	}
+}
+
+func Test_buildSchema(t *testing.T) {
+	catalog := introspectedCatalog{
+		tables: map[string]string{"students": "enrolled students", "marks": "", "logs": ""},
+		enums:  map[string][]string{"status": {"active", "inactive"}},
+		columns: []introspectedColumn{
+			{tableName: "students", columnName: "roll", dataType: "integer", notNull: true, defaultExpr: "nextval('students_roll_seq'::regclass)"},
+			{tableName: "students", columnName: "name", dataType: "character varying(50)", notNull: true, description: "full name"},
+			{tableName: "students", columnName: "status", dataType: "status", defaultExpr: "'active'::status"},
+			{tableName: "marks", columnName: "id", dataType: "bigint", notNull: true, identity: true},
+			{tableName: "marks", columnName: "roll", dataType: "integer"},
+			{tableName: "marks", columnName: "subject", dataType: "text"},
+			{tableName: "marks", columnName: "score", dataType: "double precision"},
+			{tableName: "logs", columnName: "message", dataType: "text"},
+			{tableName: "logs", columnName: "at", dataType: "timestamp with time zone"},
+		},
+		constraints: []introspectedConstraint{
+			{tableName: "marks", name: "marks_roll_fkey", kind: "f", columns: []string{"roll"}, foreignTable: "students", foreignColumns: []string{"roll"}, onUpdate: "CASCADE", onDelete: "SET NULL"},
+			{tableName: "marks", name: "marks_roll_subject_key", kind: "u", columns: []string{"roll", "subject"}},
+			{tableName: "marks", name: "marks_score_check", kind: "c", columns: []string{"score"}, definition: "CHECK (((score >= (0)::double precision) AND (score <= (100)::double precision)))"},
+			{tableName: "marks", name: "marks_pkey", kind: "p", columns: []string{"id"}},
+			{tableName: "students", name: "students_pkey", kind: "p", columns: []string{"roll"}},
+			{tableName: "students", name: "students_name_key", kind: "u", columns: []string{"name"}},
+		},
+	}
+
+	dbSchema, report := catalog.buildSchema()
+
+	wantReport := []string{
+		"marks.score: double precision read as real",
+		"marks: unique (roll, subject) not mapped",
+		"logs: no primary key, app requires one",
+	}
+	if !reflect.DeepEqual(report, wantReport) {
+		t.Errorf("buildSchema() report = %q, want %q", report, wantReport)
+	}
+
+	students, marks := dbSchema.Tables["students"], dbSchema.Tables["marks"]
+	if students.PrimaryKey != "roll" || students.Description != "enrolled students" || students.Columns["roll"].DefaultExpr != "nextval('students_roll_seq')" {
+		t.Errorf("buildSchema() students = %+v", students)
+	}
+	if name := students.Columns["name"]; !name.Unique || !name.NotNull || name.Max != "50" || name.Description != "full name" {
+		t.Errorf("buildSchema() students.name = %+v", name)
+	}
+	if status := students.Columns["status"]; status.EnumRef != "status" || status.Default != "active" {
+		t.Errorf("buildSchema() students.status = %+v", status)
+	}
+	if marks.PrimaryKey != "id" || marks.PrimaryKeyStrategy != "identity" {
+		t.Errorf("buildSchema() marks key = %s, %s", marks.PrimaryKey, marks.PrimaryKeyStrategy)
+	}
+	if roll := marks.Columns["roll"]; roll.ForeignTable != "students" || roll.OnUpdate != "CASCADE" || roll.OnDelete != "SET NULL" {
+		t.Errorf("buildSchema() marks.roll = %+v", roll)
+	}
+	if score := marks.Columns["score"]; score.Min != "0" || score.Max != "100" {
+		t.Errorf("buildSchema() marks.score = %+v", score)
+	}
+
+	// schema.json as written is valid without sources
+	encoded, err := json.Marshal(dbSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written DB
+	if err := json.Unmarshal(encoded, &written); err != nil {
+		t.Fatal(err)
+	}
+	if err := written.validateSchema(); err != nil {
+		t.Errorf("validateSchema() error = %v", err)
+	}
+
+	appConfig := AppCongif{Tables: map[string]TableConfig{}}
+	appConfig.setTables(&dbSchema)
+	if columns := appConfig.Tables["students"].ReadAllConfig.Columns; !slices.Contains(columns, "status") {
+		t.Errorf("setTables() students columns = %v", columns)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// columns added to every table in lineage mode (DB.Lineage)
+// _source_file & _source_row are filled during insertion, _loaded_at by its default
+var lineageColumns = []Column{
+	{ColumnName: "_source_file", DataType: "text", Description: "source file of the row"},
+	{ColumnName: "_source_row", DataType: "integer", Description: "row no. of the row in its source file"},
+	{ColumnName: "_loaded_at", DataType: "timestamptz", Description: "time the row was loaded", defaultSQL: "DEFAULT now()"},
+}
+
+// returns the lineage columns of table, nil if lineage mode is off
+func (table Table) LineageColumns() []Column {
+	if !table.lineage {
+		return nil
+	}
+	return lineageColumns
+}
+
+// returns the lineage columns filled during insertion, nil if lineage mode is off
+func (table *Table) lineageHeaders() []string {
+	if !table.lineage {
+		return nil
+	}
+	return []string{"_source_file", "_source_row"}
+}
+
+// true if columnName is a lineage column of table
+func (table *Table) isLineageColumn(columnName string) bool {
+	for _, column := range table.LineageColumns() {
+		if column.ColumnName == columnName {
+			return true
+		}
+	}
+	return false
+}
+
+// sets the lineage mode of table, the names of lineage columns are reserved
+func (table *Table) setLineage(lineage bool) error {
+	table.lineage = lineage
+
+	for _, column := range table.LineageColumns() {
+		if _, ok := table.Columns[column.ColumnName]; ok {
+			return fmt.Errorf("column %s is reserved for lineage", column.ColumnName)
+		}
+	}
+
+	return nil
+}
+
+// returns the SQL values of the lineage headers of a row, e.g. , 'students.csv', 4
+func (table *Table) lineageValues(rowIdx int) string {
+	if !table.lineage {
+		return ""
+	}
+
+	fileName, row := table.rowSource(rowIdx)
+	fileName = cmp.Or(fileName, table.sourceName())
+
+	return fmt.Sprintf(", '%s', %d", strings.ReplaceAll(fileName, "'", "''"), row)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_setLineage(t *testing.T) {
+	table := Table{TableName: "students", Columns: map[string]Column{"_source_row": {ColumnName: "_source_row"}}}
+	if err := table.setLineage(false); err != nil {
+		t.Errorf("setLineage(false) error = %v", err)
+	}
+	if err := table.setLineage(true); err == nil {
+		t.Errorf("setLineage(true) expected an error for reserved column")
+	}
+}
+
+func Test_lineageValues(t *testing.T) {
+	table := Table{
+		TableName: "orders",
+		FileName:  "orders.csv",
+		shards:    []shardStart{{fileName: "orders_1.csv", row: 2, fileRow: 2}, {fileName: "o'rders_2.csv", row: 5, fileRow: 1}},
+	}
+
+	if got := table.lineageValues(3); got != "" {
+		t.Errorf("lineageValues() without lineage = %q", got)
+	}
+
+	table.lineage = true
+	tests := []struct {
+		rowIdx int
+		want   string
+	}{
+		{rowIdx: 3, want: ", 'orders_1.csv', 3"},
+		{rowIdx: 6, want: ", 'o''rders_2.csv', 2"},
+	}
+	for _, tt := range tests {
+		if got := table.lineageValues(tt.rowIdx); got != tt.want {
+			t.Errorf("lineageValues(%d) = %q, want %q", tt.rowIdx, got, tt.want)
+		}
+	}
+}
@@ -1,31 +1,68 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
 func main() {
 	args := os.Args
-	argsMessage := "Invalid args! Provide:\n'schema' to generate schema.json or \n'sql' to generate db.sql or \n'app' to generate app"
+	argsMessage := "Invalid args! Provide:\n'schema' to generate schema.json (--merge to keep manual edits) or \n'sql' to generate db.sql or \n'app' to generate app or \n'profile' to generate a data profile report (--format md|html) or \n'sync' to sync changed sources into an existing database (--apply --db <connection> --delete) or \n'normalize' to propose dimension tables for repeated groups (--write to update schema.json) or \n'introspect' to generate schema.json & appConfig.json from an existing database (--db <connection> --schema public)"
 
-	if len(args) != 2 {
+	if len(args) < 2 {
 		log.Fatal(argsMessage)
 	}
 
 	input := strings.ToLower(strings.TrimSpace(args[1]))
 
 	if input == "schema" {
-		err := generateInititalSchema()
+		var merge bool
+
+		schemaFlags := flag.NewFlagSet("schema", flag.ExitOnError)
+		schemaFlags.BoolVar(&merge, "merge", false, "re-infer the CSVs while keeping manual edits of existing schema.json")
+		schemaFlags.Parse(args[2:])
+
+		report, err := generateInititalSchema(merge)
 		if err != nil {
 			log.Fatalf("Failed to generate initial schema: %v", err)
 		}
-		fmt.Println("Generated Schema successfully!")
+
+		for _, line := range report {
+			fmt.Println(line)
+		}
+
+		if merge {
+			fmt.Println("Merged Schema successfully!")
+		} else {
+			fmt.Println("Generated Schema successfully!")
+		}
 	} else if input == "sql" {
 		var dbSchema DB
+		var sqlOptions SQLOptions
+		var maxErrors string
+
+		sqlFlags := flag.NewFlagSet("sql", flag.ExitOnError)
+		sqlFlags.BoolVar(&sqlOptions.IfNotExists, "if-not-exists", false, "skip tables & constraints which already exist, ignore conflicting rows, tables without a primary key in the source are filled only if empty")
+		sqlFlags.BoolVar(&sqlOptions.DropExisting, "drop-existing", false, "drop existing tables, functions & triggers before creating them")
+		sqlFlags.BoolVar(&sqlOptions.Split, "split", false, "write schema.sql, data.sql & constraints.sql instead of db.sql")
+		sqlFlags.IntVar(&sqlOptions.HashWorkers, "hash-workers", runtime.NumCPU(), "number of goroutines used for hashing")
+		sqlFlags.BoolVar(&sqlOptions.Reject, "reject", false, "write invalid rows to data/rejects/<table>.csv instead of aborting")
+		sqlFlags.StringVar(&maxErrors, "max-errors", "", "rejected rows allowed per table, a count (100) or a percentage (0.5%), 0 fails on any reject, implies --reject")
+		sqlFlags.Parse(args[2:])
+
+		maxErrorCount, maxErrorRate, err := parseErrorBudget(maxErrors)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		sqlOptions.MaxErrors, sqlOptions.MaxErrorRate = maxErrorCount, maxErrorRate
+		sqlOptions.Reject = sqlOptions.Reject || maxErrors != ""
 
 		basePath, err := os.Getwd()
 
@@ -43,6 +80,10 @@ func main() {
 			log.Fatalf("Schema Validation Failed: %v", err)
 		}
 
+		if err := dbSchema.requireSources("sql"); err != nil {
+			log.Fatal(err)
+		}
+
 		appConfig := AppCongif{
 			SchemaPath: schemaFilePath,
 			Tables:     make(map[string]TableConfig, len(dbSchema.Tables)),
@@ -55,24 +96,49 @@ func main() {
 			log.Fatalf("Failed to write appConfig.json: %v", err)
 		}
 
-		insertionBuffer, err := dbSchema.dataInsertion()
+		insertionBuffer, report, err := dbSchema.dataInsertion(&sqlOptions)
+		for _, line := range report {
+			fmt.Println(line)
+		}
+
 		if err != nil {
 			log.Fatalf("error while data insertion: %v", err)
 		}
 
-		createBuffer, err := dbSchema.createStatements()
+		createBuffer, err := dbSchema.createStatements(&sqlOptions)
 		if err != nil {
 			log.Fatalf("error while creating sql statements: %v", err)
 		}
 
-		foreignBuffer, err := dbSchema.foreignKeyStatements()
+		foreignBuffer, err := dbSchema.foreignKeyStatements(&sqlOptions)
 		if err != nil {
 			log.Fatalf("error while adding foreign key constriants: %v", err)
 		}
 
+		if sqlOptions.Split {
+			sqlFiles := []struct {
+				fileName string
+				buffer   *bytes.Buffer
+			}{
+				{fileName: "schema.sql", buffer: createBuffer},
+				{fileName: "data.sql", buffer: insertionBuffer},
+				{fileName: "constraints.sql", buffer: foreignBuffer},
+			}
+
+			for _, sqlFile := range sqlFiles {
+				filePath := filepath.Join(basePath, "data", sqlFile.fileName)
+				if err := writeFile(filePath, wrapTransaction(sqlFile.buffer)...); err != nil {
+					log.Fatalf("error while creating %s: %v", sqlFile.fileName, err)
+				}
+			}
+
+			fmt.Println("schema.sql, data.sql & constraints.sql generated")
+			return
+		}
+
 		filePath := filepath.Join(basePath, "data", "db.sql")
 
-		if err := writeFile(filePath, createBuffer, insertionBuffer, foreignBuffer); err != nil {
+		if err := writeFile(filePath, wrapTransaction(createBuffer, insertionBuffer, foreignBuffer)...); err != nil {
 			log.Fatalf("error while creating db.sql: %v", err)
 		}
 
@@ -116,6 +182,99 @@ func main() {
 		}
 
 		fmt.Println("app generated")
+	} else if input == "profile" {
+		var format string
+		var top int
+
+		profileFlags := flag.NewFlagSet("profile", flag.ExitOnError)
+		profileFlags.StringVar(&format, "format", "md", "format of the report, md or html")
+		profileFlags.IntVar(&top, "top", 5, "number of most frequent values shown per column")
+		profileFlags.Parse(args[2:])
+
+		filePath, err := generateProfile(strings.ToLower(format), top)
+		if err != nil {
+			log.Fatalf("Failed to generate profile: %v", err)
+		}
+
+		fmt.Printf("%s generated\n", filepath.Base(filePath))
+	} else if input == "sync" {
+		var dbSchema DB
+		var syncOptions SyncOptions
+
+		syncFlags := flag.NewFlagSet("sync", flag.ExitOnError)
+		syncFlags.BoolVar(&syncOptions.Apply, "apply", false, "run the sync against the database instead of writing sync.sql")
+		syncFlags.BoolVar(&syncOptions.Delete, "delete", false, "delete rows whose primary key isn't found in the sources")
+		syncFlags.StringVar(&syncOptions.Database, "db", os.Getenv("DATABASE_URL"), "connection string of the database, defaults to DATABASE_URL")
+		syncFlags.IntVar(&syncOptions.HashWorkers, "hash-workers", runtime.NumCPU(), "number of goroutines used for hashing")
+		syncFlags.Parse(args[2:])
+
+		basePath, err := os.Getwd()
+
+		if err != nil {
+			log.Fatalf("error while reading base path: %v", err)
+		}
+
+		schemaFilePath := filepath.Join(basePath, "data", "schema.json")
+
+		if err := readJsonFile(schemaFilePath, &dbSchema); err != nil {
+			log.Fatalf("Failed to parse DB schema: %v", err)
+		}
+
+		if err := dbSchema.validateSchema(); err != nil {
+			log.Fatalf("Schema Validation Failed: %v", err)
+		}
+
+		if err := dbSchema.requireSources("sync"); err != nil {
+			log.Fatal(err)
+		}
+
+		report, err := dbSchema.syncData(&syncOptions)
+		for _, line := range report {
+			fmt.Println(line)
+		}
+
+		if err != nil {
+			log.Fatalf("error while syncing data: %v", err)
+		}
+
+		if syncOptions.Apply {
+			fmt.Println("sync applied")
+		} else {
+			fmt.Println("sync.sql generated")
+		}
+	} else if input == "normalize" {
+		var write bool
+
+		normalizeFlags := flag.NewFlagSet("normalize", flag.ExitOnError)
+		normalizeFlags.BoolVar(&write, "write", false, "add the proposed dimension tables to schema.json")
+		normalizeFlags.Parse(args[2:])
+
+		report, err := generateNormalization(write)
+		for _, line := range report {
+			fmt.Println(line)
+		}
+
+		if err != nil {
+			log.Fatalf("Failed to normalize schema: %v", err)
+		}
+	} else if input == "introspect" {
+		var introspectOptions IntrospectOptions
+
+		introspectFlags := flag.NewFlagSet("introspect", flag.ExitOnError)
+		introspectFlags.StringVar(&introspectOptions.Database, "db", os.Getenv("DATABASE_URL"), "connection string of the database, defaults to DATABASE_URL")
+		introspectFlags.StringVar(&introspectOptions.Schema, "schema", "public", "postgres schema of the tables")
+		introspectFlags.Parse(args[2:])
+
+		report, err := generateIntrospection(&introspectOptions)
+		for _, line := range report {
+			fmt.Println(line)
+		}
+
+		if err != nil {
+			log.Fatalf("Failed to introspect database: %v", err)
+		}
+
+		fmt.Println("schema.json & appConfig.json generated")
 	} else {
 		log.Fatal(argsMessage)
 	}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// merges the re-inferred schema into dbSchema, keeping manual edits of columns whose type is still compatible
+// new tables & columns are added, tables & columns missing from the sources are kept & listed in the report along with type changes
+func (dbSchema *DB) mergeInferredSchema(inferred DB) []string {
+	report := []string{}
+
+	if dbSchema.Tables == nil {
+		dbSchema.Tables = make(map[string]Table, len(inferred.Tables))
+	}
+	if dbSchema.Enums == nil {
+		dbSchema.Enums = map[string]Enum{}
+	}
+	dbSchema.BasePath = inferred.BasePath
+
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		// derived tables are kept along with the table owning their source
+		root, err := dbSchema.derivationRoot(tableName)
+		if err != nil {
+			root = tableName
+		}
+
+		if _, ok := inferred.Tables[root]; ok {
+			continue
+		}
+
+		if source := dbSchema.Tables[root]; source.FileName == "" && len(source.Files) == 0 {
+			report = append(report, fmt.Sprintf("! table %s kept, it has no source", tableName))
+		} else {
+			report = append(report, fmt.Sprintf("! table %s kept, its source wasn't found", tableName))
+		}
+	}
+
+	for _, tableName := range slices.Sorted(maps.Keys(inferred.Tables)) {
+		inferredTable := inferred.Tables[tableName]
+
+		table, ok := dbSchema.Tables[tableName]
+		if !ok {
+			dbSchema.Tables[tableName] = inferredTable
+			report = append(report, fmt.Sprintf("+ table %s added", tableName))
+			continue
+		}
+
+		// columns moved to derived tables aren't added back
+		for columnName := range dbSchema.derivedColumns(tableName) {
+			delete(inferredTable.Columns, columnName)
+		}
+
+		report = append(report, table.mergeInferredTable(inferredTable)...)
+		dbSchema.Tables[tableName] = table
+	}
+
+	report = append(report, dbSchema.removeDanglingForeignKeys()...)
+
+	return report
+}
+
+// merges the re-inferred columns of a table, table settings are kept unless their columns are removed
+func (table *Table) mergeInferredTable(inferred Table) []string {
+	report := []string{}
+	tableName := inferred.TableName
+
+	if table.Columns == nil {
+		table.Columns = make(map[string]Column, len(inferred.Columns))
+	}
+	table.FileName, table.Files, table.Format, table.Sheet, table.Entry = inferred.FileName, inferred.Files, inferred.Format, inferred.Sheet, inferred.Entry
+	table.Headers = inferred.Headers
+	if table.Label == "" {
+		table.Label = inferred.Label
+	}
+
+	for _, columnName := range slices.Sorted(maps.Keys(table.Columns)) {
+		if _, ok := inferred.Columns[columnName]; !ok {
+			report = append(report, fmt.Sprintf("! column %s.%s kept, it isn't present in %s", tableName, columnName, inferred.sourceName()))
+		}
+	}
+
+	for _, columnName := range slices.Sorted(maps.Keys(inferred.Columns)) {
+		inferredColumn := inferred.Columns[columnName]
+
+		column, ok := table.Columns[columnName]
+		if !ok {
+			table.Columns[columnName] = inferredColumn
+			report = append(report, fmt.Sprintf("+ column %s.%s added as %s", tableName, columnName, inferredColumn.DataType))
+			continue
+		}
+
+		if column.Label == "" {
+			column.Label = inferredColumn.Label
+			table.Columns[columnName] = column
+		}
+
+		if column.NotNull && inferredColumn.hasNulls && columnName != table.PrimaryKey {
+			report = append(report, fmt.Sprintf("! column %s.%s is notNull, but %s has null values", tableName, columnName, inferred.sourceName()))
+		}
+
+		// columns added by hand, e.g. computed columns, take the inferred type
+		if column.DataType == "" && inferredColumn.DataType != "" {
+			column.DataType = inferredColumn.DataType
+			table.Columns[columnName] = column
+			report = append(report, fmt.Sprintf("+ column %s.%s typed as %s", tableName, columnName, column.DataType))
+			continue
+		}
+
+		if isCompatibleType(column.DataType, inferredColumn.DataType) {
+			continue
+		}
+
+		message := fmt.Sprintf("! column %s.%s type changed from %s to %s", tableName, columnName, column.DataType, inferredColumn.DataType)
+		if dropped := column.typeDependentEdits(inferredColumn.DataType); len(dropped) > 0 {
+			message += fmt.Sprintf(", review dropped %s", strings.Join(dropped, ", "))
+		}
+		report = append(report, message)
+
+		// settings independent of type are kept, transforms & expressions work on the source text
+		inferredColumn.Label = column.Label
+		inferredColumn.Description = column.Description
+		inferredColumn.NotNull = column.NotNull
+		inferredColumn.Unique = column.Unique
+		inferredColumn.ForeignTable = column.ForeignTable
+		inferredColumn.ForeignField = column.ForeignField
+		inferredColumn.OnUpdate = column.OnUpdate
+		inferredColumn.OnDelete = column.OnDelete
+		inferredColumn.Transforms = column.Transforms
+		inferredColumn.Expr = column.Expr
+		if column.isHashable(inferredColumn.DataType) {
+			inferredColumn.Hash, inferredColumn.HashAlgorithm, inferredColumn.HashCost = column.Hash, column.HashAlgorithm, column.HashCost
+		}
+		table.Columns[columnName] = inferredColumn
+	}
+
+	if _, ok := table.Columns[table.PrimaryKey]; table.PrimaryKey != "" && !ok {
+		report = append(report, fmt.Sprintf("! table %s primary key %s removed, %s is used", tableName, table.PrimaryKey, inferred.PrimaryKey))
+		table.PrimaryKey = inferred.PrimaryKey
+		table.PrimaryKeyStrategy = inferred.PrimaryKeyStrategy
+	}
+
+	// checks may use removed columns or compare with changed types
+	for _, checkName := range slices.Sorted(maps.Keys(table.Checks)) {
+		root, err := parseCheck(table.Checks[checkName])
+		if err == nil {
+			err = root.resolve(table.Columns)
+		}
+		if err != nil {
+			report = append(report, fmt.Sprintf("! check %s of table %s needs attention: %v", checkName, tableName, err))
+		}
+	}
+
+	return report
+}
+
+// true if the existing type can hold the values of inferred type, empty inferred type indicates no values
+func isCompatibleType(existing, inferred string) bool {
+	if inferred == "" || existing == inferred || existing == "text" {
+		return true
+	}
+
+	if arrayDims(existing) != arrayDims(inferred) {
+		return existing == "jsonb" && arrayDims(inferred) > 0
+	}
+
+	existing, inferred = elementType(existing), elementType(inferred)
+	if existing == inferred || existing == "text" {
+		return true
+	}
+
+	if baseType(existing) == "numeric" {
+		return isIntegerType(inferred) || inferred == "real" || baseType(inferred) == "numeric"
+	}
+
+	return mergeDetectedTypes(existing, inferred) == existing
+}
+
+// returns the names of non-empty column settings which depend on the datatype, dropped when it changes to datatype
+func (column *Column) typeDependentEdits(datatype string) []string {
+	edits := []string{}
+
+	add := func(name string, isSet bool) {
+		if isSet {
+			edits = append(edits, name)
+		}
+	}
+
+	add("min", column.Min != "")
+	add("max", column.Max != "")
+	add("enums", len(column.Enums) > 0)
+	add("enumRef", column.EnumRef != "")
+	add("default", column.Default != nil)
+	add("defaultExpr", column.DefaultExpr != "")
+	add("hash", column.Hash && !column.isHashable(datatype))
+	add("pattern", column.Pattern != "")
+	add("format", column.Format != "")
+	add("inputFormat", column.InputFormat != "")
+	add("timezone", column.Timezone != "")
+	add("parsing", column.Parsing != nil)
+
+	return edits
+}
+
+// true if the hash settings of column hold for datatype, only text & text[] are hashed
+func (column *Column) isHashable(datatype string) bool {
+	return column.Hash && (datatype == "text" || datatype == "text[]")
+}
+
+// clears foreign keys referencing removed tables or columns
+func (dbSchema *DB) removeDanglingForeignKeys() []string {
+	report := []string{}
+
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		table := dbSchema.Tables[tableName]
+
+		for _, columnName := range slices.Sorted(maps.Keys(table.Columns)) {
+			column := table.Columns[columnName]
+			if column.ForeignTable == "" || column.ForeignTable == "__" {
+				continue
+			}
+
+			if foreignTable, ok := dbSchema.Tables[column.ForeignTable]; ok {
+				if _, ok := foreignTable.Columns[column.ForeignField]; ok {
+					continue
+				}
+			}
+
+			report = append(report, fmt.Sprintf("! column %s.%s foreign key to %s.%s removed", tableName, columnName, column.ForeignTable, column.ForeignField))
+			column.ForeignTable, column.ForeignField, column.OnUpdate, column.OnDelete = "", "", "", ""
+			table.Columns[columnName] = column
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_isCompatibleType(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		inferred string
+		want     bool
+	}{
+		{name: "same", existing: "integer", inferred: "integer", want: true},
+		{name: "no values", existing: "date", inferred: "", want: true},
+		{name: "text holds all", existing: "text", inferred: "integer[][]", want: true},
+		{name: "widened by user", existing: "bigint", inferred: "integer", want: true},
+		{name: "real holds integers", existing: "real[]", inferred: "integer[]", want: true},
+		{name: "numeric holds reals", existing: "numeric(10,2)", inferred: "real", want: true},
+		{name: "numeric holds decimals", existing: "numeric(10,2)", inferred: "numeric(14,3)", want: true},
+		{name: "real holds decimals", existing: "real", inferred: "numeric(4,2)", want: true},
+		{name: "integer to decimal", existing: "integer", inferred: "numeric(11,1)", want: false},
+		{name: "jsonb holds arrays", existing: "jsonb", inferred: "integer[]", want: true},
+		{name: "narrower", existing: "integer", inferred: "bigint", want: false},
+		{name: "integer to real", existing: "integer[][]", inferred: "real[][]", want: false},
+		{name: "dimensions changed", existing: "integer[]", inferred: "integer[][]", want: false},
+		{name: "date to text", existing: "date", inferred: "text", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCompatibleType(tt.existing, tt.inferred); got != tt.want {
+				t.Errorf("isCompatibleType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_mergeInferredSchema(t *testing.T) {
+	existing := DB{
+		Timezone: "Asia/Kolkata",
+		Tables: map[string]Table{
+			"orders": {
+				TableName:  "orders",
+				FileName:   "orders.csv",
+				PrimaryKey: "id",
+				Checks:     map[string]string{"positive_qty": "qty > 0"},
+				Columns: map[string]Column{
+					"id":          {ColumnName: "id", DataType: "integer", Min: "1"},
+					"qty":         {ColumnName: "qty", DataType: "integer", Max: "100", NotNull: true, Transforms: []Transform{{Type: "replace", Pattern: "pcs$"}}},
+					"customer_id": {ColumnName: "customer_id", DataType: "integer", ForeignTable: "customers", ForeignField: "customer_id", OnDelete: "SET NULL"},
+					"legacy":      {ColumnName: "legacy", DataType: "text"},
+					"pin":         {ColumnName: "pin", DataType: "text[]", Hash: true, HashAlgorithm: "argon2id"},
+					"created_at":  {ColumnName: "created_at", DataType: "timestamptz", DefaultExpr: "now()"},
+				},
+			},
+			"students": {
+				TableName: "students",
+				Columns:   map[string]Column{"roll": {ColumnName: "roll", DataType: "integer"}},
+			},
+			"customers": {
+				TableName: "customers",
+				FileName:  "customers.csv",
+				Columns:   map[string]Column{"customer_id": {ColumnName: "customer_id", DataType: "integer"}},
+			},
+		},
+	}
+
+	inferred := DB{
+		BasePath: "/data",
+		Tables: map[string]Table{
+			"orders": {
+				TableName:  "orders",
+				FileName:   "orders.csv",
+				PrimaryKey: "id",
+				Columns: map[string]Column{
+					"id":          {ColumnName: "id", DataType: "integer"},
+					"qty":         {ColumnName: "qty", DataType: "real", hasNulls: true},
+					"customer_id": {ColumnName: "customer_id", DataType: "integer"},
+					"note":        {ColumnName: "note", DataType: "text"},
+					"pin":         {ColumnName: "pin", DataType: "text"},
+				},
+			},
+			"items": {
+				TableName: "items",
+				FileName:  "items.csv",
+				Columns:   map[string]Column{"sku": {ColumnName: "sku", DataType: "text"}},
+			},
+		},
+	}
+
+	report := existing.mergeInferredSchema(inferred)
+
+	wantReport := []string{
+		"! table customers kept, its source wasn't found",
+		"! table students kept, it has no source",
+		"+ table items added",
+		"! column orders.created_at kept, it isn't present in orders.csv",
+		"! column orders.legacy kept, it isn't present in orders.csv",
+		"+ column orders.note added as text",
+		"! column orders.pin type changed from text[] to text",
+		"! column orders.qty is notNull, but orders.csv has null values",
+		"! column orders.qty type changed from integer to real, review dropped max",
+	}
+	if !reflect.DeepEqual(report, wantReport) {
+		t.Errorf("DB.mergeInferredSchema() report = %q, want %q", report, wantReport)
+	}
+
+	orders := existing.Tables["orders"]
+	wantColumns := map[string]Column{
+		"id":          {ColumnName: "id", DataType: "integer", Min: "1"},
+		"qty":         {ColumnName: "qty", DataType: "real", NotNull: true, Transforms: []Transform{{Type: "replace", Pattern: "pcs$"}}, hasNulls: true},
+		"pin":         {ColumnName: "pin", DataType: "text", Hash: true, HashAlgorithm: "argon2id"},
+		"customer_id": {ColumnName: "customer_id", DataType: "integer", ForeignTable: "customers", ForeignField: "customer_id", OnDelete: "SET NULL"},
+		"legacy":      {ColumnName: "legacy", DataType: "text"},
+		"created_at":  {ColumnName: "created_at", DataType: "timestamptz", DefaultExpr: "now()"},
+		"note":        {ColumnName: "note", DataType: "text"},
+	}
+	if !reflect.DeepEqual(orders.Columns, wantColumns) {
+		t.Errorf("DB.mergeInferredSchema() columns = %v, want %v", orders.Columns, wantColumns)
+	}
+
+	if orders.Checks["positive_qty"] != "qty > 0" || existing.Timezone != "Asia/Kolkata" || existing.BasePath != "/data" {
+		t.Errorf("DB.mergeInferredSchema() didn't keep table & project settings")
+	}
+
+	for _, tableName := range []string{"items", "customers", "students"} {
+		if _, ok := existing.Tables[tableName]; !ok {
+			t.Errorf("DB.mergeInferredSchema() doesn't have %s table", tableName)
+		}
+	}
+}
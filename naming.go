@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// max length of Postgres identifiers in bytes (NAMEDATALEN - 1)
+const maxIdentifierLen = 63
+
+var reservedWordPolicies = []string{"keep", "suffix", "error"}
+
+// reserved key words of Postgres, these can be used as quoted identifiers only
+var reservedWords = []string{
+	"all", "analyse", "analyze", "and", "any", "array", "as", "asc", "asymmetric", "authorization", "binary", "both",
+	"case", "cast", "check", "collate", "collation", "column", "concurrently", "constraint", "create", "cross",
+	"current_catalog", "current_date", "current_role", "current_schema", "current_time", "current_timestamp",
+	"current_user", "default", "deferrable", "desc", "distinct", "do", "else", "end", "except", "false", "fetch", "for",
+	"foreign", "freeze", "from", "full", "grant", "group", "having", "ilike", "in", "initially", "inner", "intersect",
+	"into", "is", "isnull", "join", "lateral", "leading", "left", "like", "limit", "localtime", "localtimestamp",
+	"natural", "not", "notnull", "null", "offset", "on", "only", "or", "order", "outer", "overlaps", "placing",
+	"primary", "references", "returning", "right", "select", "session_user", "similar", "some", "symmetric",
+	"system_user", "table", "tablesample", "then", "to", "trailing", "true", "union", "unique", "user", "using",
+	"variadic", "verbose", "when", "where", "window", "with",
+}
+
+// NamingOptions of the inferred schema
+func defaultNamingOptions() NamingOptions {
+	return NamingOptions{Transliterate: true, ReservedWords: "keep", MaxLength: maxIdentifierLen}
+}
+
+func (naming *NamingOptions) validate() error {
+	naming.ReservedWords = strings.ToLower(strings.TrimSpace(naming.ReservedWords))
+	if naming.ReservedWords == "" {
+		naming.ReservedWords = "keep"
+	}
+
+	if !slices.Contains(reservedWordPolicies, naming.ReservedWords) {
+		return fmt.Errorf("invalid reservedWords %s, should be one of %v", naming.ReservedWords, reservedWordPolicies)
+	}
+
+	if naming.MaxLength == 0 {
+		naming.MaxLength = maxIdentifierLen
+	}
+
+	// room for the hash suffix of truncated names
+	if naming.MaxLength < 16 || naming.MaxLength > maxIdentifierLen {
+		return fmt.Errorf("invalid maxLength %d, should be between 16 and %d", naming.MaxLength, maxIdentifierLen)
+	}
+
+	return nil
+}
+
+// returns the table or column name of text, e.g. a header or file name
+// runs of characters other than ASCII letters & digits are replaced by _
+func (naming NamingOptions) sanitize(text string) string {
+	text = strings.TrimSpace(text)
+
+	if naming.Transliterate {
+		text = transliterate(text)
+	}
+
+	if naming.SnakeCase {
+		text = splitCamelCase(text)
+	}
+
+	var builder strings.Builder
+	separated := false
+	for _, char := range text {
+		if char < utf8.RuneSelf && (unicode.IsLetter(char) || unicode.IsDigit(char)) {
+			builder.WriteRune(char)
+			separated = false
+		} else if !separated {
+			builder.WriteByte('_')
+			separated = true
+		}
+	}
+	name := builder.String()
+
+	if naming.SnakeCase {
+		name = strings.Trim(strings.ToLower(name), "_")
+	}
+
+	if naming.ReservedWords == "suffix" && isReservedWord(name) {
+		name += "_"
+	}
+
+	return naming.truncate(name)
+}
+
+// truncates names longer than MaxLength bytes, a hash of the full name is appended to keep them distinct
+// e.g. a_very_long_name -> a_very_4f2c9a1b
+func (naming NamingOptions) truncate(name string) string {
+	maxLength := naming.MaxLength
+	if maxLength == 0 {
+		maxLength = maxIdentifierLen
+	}
+
+	if len(name) <= maxLength {
+		return name
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	suffix := fmt.Sprintf("_%08x", hash.Sum32())
+
+	return strings.TrimRight(name[:maxLength-len(suffix)], "_") + suffix
+}
+
+// validates a table or column name against the naming options, label is the original text used in messages
+func (naming NamingOptions) validateName(name, label string) error {
+	display := name
+	if label != "" && label != name {
+		display = fmt.Sprintf("%s (%s)", name, label)
+	}
+
+	switch {
+	case len(name) == 0:
+		return errors.New("empty name")
+	case len(name) > maxIdentifierLen:
+		return fmt.Errorf("name %s is longer than %d bytes", display, maxIdentifierLen)
+	case name != naming.sanitize(name):
+		return fmt.Errorf("name %s isn't sanitized", display)
+	case naming.ReservedWords == "error" && isReservedWord(name):
+		return fmt.Errorf("name %s is a reserved word", display)
+	}
+
+	return nil
+}
+
+// checks that the original headers of columns map to different columns
+func (table *Table) validateLabels() error {
+	if len(table.Headers) > 0 {
+		return nil
+	}
+
+	labels := make(map[string]string, len(table.Columns)) // key: column name of label
+	for _, columnName := range slices.Sorted(maps.Keys(table.Columns)) {
+		label := table.Columns[columnName].Label
+		if label == "" {
+			continue
+		}
+
+		name := table.naming.sanitize(label)
+		if other, ok := labels[name]; ok {
+			return fmt.Errorf("headers %q and %q of table %s both map to column %s", other, label, table.TableName, name)
+		}
+		labels[name] = label
+	}
+
+	return nil
+}
+
+func isReservedWord(name string) bool {
+	return slices.Contains(reservedWords, strings.ToLower(name))
+}
+
+// separates the words of camelCase & PascalCase text by spaces, e.g. StudentID -> Student ID, HTTPServer -> HTTP Server
+func splitCamelCase(text string) string {
+	runes := []rune(text)
+	var builder strings.Builder
+
+	for idx, char := range runes {
+		if idx > 0 && unicode.IsUpper(char) {
+			prev := runes[idx-1]
+			nextIsLower := idx+1 < len(runes) && unicode.IsLower(runes[idx+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				builder.WriteRune(' ')
+			}
+		}
+		builder.WriteRune(char)
+	}
+
+	return builder.String()
+}
+
+// ASCII spellings of latin letters with diacritics, greek & cyrillic letters
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
+
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l",
+	'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f",
+	'χ': "ch", 'ψ': "ps", 'ω': "o", 'ά': "a", 'έ': "e", 'ή': "i", 'ί': "i", 'ό': "o", 'ύ': "y", 'ώ': "o",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i", 'й': "y",
+	'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f",
+	'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+}
+
+// base letters of latin letters with diacritics, grouped by base
+var latinDiacritics = map[string]string{
+	"a": "àáâãäåāăą", "c": "çćĉċč", "d": "ď", "e": "èéêëēĕėęě", "g": "ĝğġģ", "h": "ĥħ", "i": "ìíîïĩīĭį",
+	"j": "ĵ", "k": "ķ", "l": "ĺļľŀ", "n": "ñńņňŉ", "o": "òóôõöōŏő", "r": "ŕŗř", "s": "śŝşš", "t": "ţťŧ",
+	"u": "ùúûüũūŭůűų", "w": "ŵ", "y": "ýÿŷ", "z": "źżž",
+}
+
+func init() {
+	for base, letters := range latinDiacritics {
+		for _, letter := range letters {
+			transliterations[letter] = base
+		}
+	}
+}
+
+// devanagari letters, consonants carry the inherent a unless followed by a vowel sign or virama
+var devanagariConsonants = map[rune]string{
+	'क': "k", 'ख': "kh", 'ग': "g", 'घ': "gh", 'ङ': "n", 'च': "ch", 'छ': "chh", 'ज': "j", 'झ': "jh", 'ञ': "n",
+	'ट': "t", 'ठ': "th", 'ड': "d", 'ढ': "dh", 'ण': "n", 'त': "t", 'थ': "th", 'द': "d", 'ध': "dh", 'न': "n",
+	'प': "p", 'फ': "ph", 'ब': "b", 'भ': "bh", 'म': "m", 'य': "y", 'र': "r", 'ल': "l", 'ळ': "l", 'व': "v",
+	'श': "sh", 'ष': "sh", 'स': "s", 'ह': "h", '\u0958': "q", '\u0959': "kh", '\u095A': "g", '\u095B': "z", '\u095C': "r",
+	'\u095D': "rh", '\u095E': "f", '\u095F': "y",
+}
+
+var devanagariVowels = map[rune]string{
+	'अ': "a", 'आ': "a", 'इ': "i", 'ई': "i", 'उ': "u", 'ऊ': "u", 'ऋ': "ri", 'ए': "e", 'ऐ': "ai", 'ओ': "o", 'औ': "au",
+	'ं': "n", 'ँ': "n", 'ः': "h", 'ॐ': "om",
+}
+
+var devanagariVowelSigns = map[rune]string{
+	'ा': "a", 'ि': "i", 'ी': "i", 'ु': "u", 'ू': "u", 'ृ': "ri", 'े': "e", 'ै': "ai", 'ो': "o", 'ौ': "au", 'ॅ': "e",
+	'ॉ': "o",
+}
+
+const devanagariVirama = '्'
+const devanagariNukta = '़'
+
+// converts letters of other scripts to ASCII letters, unknown characters are kept as is
+// the inherent a of the last consonant of devanagari words is dropped, e.g. नाम -> nam
+func transliterate(text string) string {
+	var builder strings.Builder
+	runes := []rune(text)
+
+	for idx := 0; idx < len(runes); idx++ {
+		char := runes[idx]
+
+		if consonant, ok := devanagariConsonants[char]; ok {
+			builder.WriteString(consonant)
+
+			next := idx + 1
+			for next < len(runes) && runes[next] == devanagariNukta {
+				next++
+			}
+
+			if next < len(runes) {
+				if sign, ok := devanagariVowelSigns[runes[next]]; ok {
+					builder.WriteString(sign)
+					idx = next
+					continue
+				}
+
+				if runes[next] == devanagariVirama {
+					idx = next
+					continue
+				}
+
+				_, isConsonant := devanagariConsonants[runes[next]]
+				_, isVowel := devanagariVowels[runes[next]]
+				if isConsonant || isVowel {
+					builder.WriteString("a")
+				}
+			}
+
+			idx = next - 1
+			continue
+		}
+
+		if vowel, ok := devanagariVowels[char]; ok {
+			builder.WriteString(vowel)
+			continue
+		}
+
+		if char >= '०' && char <= '९' {
+			builder.WriteRune('0' + char - '०')
+			continue
+		}
+
+		lower := unicode.ToLower(char)
+		spelling, ok := transliterations[lower]
+		if !ok {
+			builder.WriteRune(char)
+			continue
+		}
+
+		if lower != char && spelling != "" {
+			spelling = strings.ToUpper(spelling[:1]) + spelling[1:]
+		}
+		builder.WriteString(spelling)
+	}
+
+	return builder.String()
+}
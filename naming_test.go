@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamingOptions_sanitize(t *testing.T) {
+	longHeader := strings.Repeat("marks obtained ", 6)
+
+	tests := []struct {
+		name   string
+		naming NamingOptions
+		text   string
+		want   string
+	}{
+		{name: "legacy", naming: NamingOptions{}, text: " Student Father ", want: "Student_Father"},
+		{name: "legacy non-ascii", naming: NamingOptions{}, text: "छात्र नाम", want: "_"},
+		{name: "accents", naming: NamingOptions{Transliterate: true}, text: "Année Scolaire", want: "Annee_Scolaire"},
+		{name: "devanagari", naming: NamingOptions{Transliterate: true}, text: "छात्र का नाम", want: "chhatr_ka_nam"},
+		{name: "cyrillic", naming: NamingOptions{Transliterate: true}, text: "Имя", want: "Imya"},
+		{name: "snake case", naming: NamingOptions{SnakeCase: true}, text: "StudentID (Old)", want: "student_id_old"},
+		{name: "snake case acronym", naming: NamingOptions{SnakeCase: true}, text: "HTTPServer2Port", want: "http_server2_port"},
+		{name: "reserved kept", naming: NamingOptions{ReservedWords: "keep"}, text: "order", want: "order"},
+		{name: "reserved suffixed", naming: NamingOptions{ReservedWords: "suffix"}, text: "User", want: "User_"},
+		{name: "truncated", naming: NamingOptions{SnakeCase: true, MaxLength: 30}, text: longHeader, want: "marks_obtained_marks_b3d82f58"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.naming.sanitize(tt.text); got != tt.want {
+				t.Errorf("NamingOptions.sanitize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamingOptions_validateName(t *testing.T) {
+	tests := []struct {
+		name      string
+		naming    NamingOptions
+		tableName string
+		label     string
+		wantErr   string
+	}{
+		{name: "valid", naming: NamingOptions{SnakeCase: true}, tableName: "student_father", label: "Student Father"},
+		{name: "not sanitized", naming: NamingOptions{SnakeCase: true}, tableName: "Student_Father", label: "Student Father", wantErr: "name Student_Father (Student Father) isn't sanitized"},
+		{name: "reserved", naming: NamingOptions{ReservedWords: "error"}, tableName: "order", wantErr: "name order is a reserved word"},
+		{name: "too long", naming: NamingOptions{}, tableName: strings.Repeat("a", 64), wantErr: "is longer than 63 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.naming.validateName(tt.tableName, tt.label)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("NamingOptions.validateName() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTable_validateLabels(t *testing.T) {
+	table := Table{
+		TableName: "students",
+		Columns: map[string]Column{
+			"father":         {ColumnName: "father", Label: "Student Father"},
+			"Student_Father": {ColumnName: "Student_Father", Label: "Student-Father"},
+		},
+	}
+
+	want := `headers "Student-Father" and "Student Father" of table students both map to column Student_Father`
+	if err := table.validateLabels(); err == nil || err.Error() != want {
+		t.Errorf("Table.validateLabels() error = %v, want %v", err, want)
+	}
+}
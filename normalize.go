@@ -0,0 +1,363 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// suffixes trimmed from key columns to name their dimension tables, e.g. college_name -> college
+var dimensionSuffixes = []string{"_name", "_code", "_id", "_key"}
+
+// a table proposed to be extracted from parent, its rows are the distinct values of key & columns
+type dimension struct {
+	parent  string
+	name    string
+	key     string   // determines the columns, kept in parent as foreign key
+	columns []string // moved from parent to the dimension
+}
+
+// detects the functional dependencies of the tables in schema.json & proposes dimension tables
+// with write, schema.json is updated with the dimension tables derived from their parents
+func generateNormalization(write bool) ([]string, error) {
+	basePath, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	schemaFilePath := filepath.Join(basePath, "data", "schema.json")
+
+	var dbSchema DB
+	if err := readJsonFile(schemaFilePath, &dbSchema); err != nil {
+		return nil, fmt.Errorf("failed to parse DB schema: %v", err)
+	}
+
+	// validation resolves the parsing options & transforms, the schema is written back as read
+	var rawSchema DB
+	if err := readJsonFile(schemaFilePath, &rawSchema); err != nil {
+		return nil, fmt.Errorf("failed to parse DB schema: %v", err)
+	}
+
+	if err := dbSchema.validateSchema(); err != nil {
+		return nil, fmt.Errorf("schema validation failed: %v", err)
+	}
+
+	if err := dbSchema.requireSources("normalize"); err != nil {
+		return nil, err
+	}
+
+	taken := map[string]bool{}
+	for tableName := range dbSchema.Tables {
+		taken[tableName] = true
+	}
+
+	dimensions := []dimension{}
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		table := dbSchema.Tables[tableName]
+		candidates := dbSchema.dimensionCandidates(&table)
+		if len(candidates) == 0 {
+			continue
+		}
+
+		rows, err := table.readValues(dbSchema.BasePath, candidates)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading %s table: %v", tableName, err)
+		}
+
+		dimensions = append(dimensions, findDimensions(tableName, candidates, rows, candidates, taken)...)
+	}
+
+	report := []string{}
+	for _, dimension := range dimensions {
+		report = append(report, fmt.Sprintf("extract %s from %s: %s determines %s", dimension.name, dimension.parent, dimension.key, strings.Join(dimension.columns, ", ")))
+
+		if write {
+			rawSchema.extractDimension(dimension)
+		}
+	}
+
+	if len(dimensions) == 0 {
+		return append(report, "no repeated groups found"), nil
+	}
+
+	if !write {
+		return append(report, "run normalize --write to update schema.json"), nil
+	}
+
+	if err := writeJsonFile(schemaFilePath, rawSchema); err != nil {
+		return report, err
+	}
+
+	return append(report, "schema.json updated"), nil
+}
+
+// returns the columns of table which may determine or depend on others, sorted by name
+// keys, hashed, computed & generated columns, arrays & json, and columns used by checks, expressions or foreign keys are left out
+// checks & expressions are read from their parsed nodes, set by validation
+func (dbSchema *DB) dimensionCandidates(table *Table) []string {
+	used := map[string]bool{}
+	for _, check := range table.checks {
+		check.root.columnNames(used)
+	}
+	for _, column := range table.Columns {
+		if column.expr != nil {
+			column.expr.columnNames(used)
+		}
+	}
+
+	referenced := map[string]bool{}
+	for _, other := range dbSchema.Tables {
+		for _, column := range other.Columns {
+			if column.ForeignTable == table.TableName {
+				referenced[column.ForeignField] = true
+			}
+		}
+	}
+
+	candidates := []string{}
+	for _, columnName := range slices.Sorted(maps.Keys(table.Columns)) {
+		column := table.Columns[columnName]
+
+		if columnName == table.PrimaryKey || column.Unique || column.Hash || column.Expr != "" || column.ForeignTable != "" || column.IsGenerated() {
+			continue
+		}
+
+		if arrayDims(column.DataType) > 0 || column.DataType == "jsonb" || referenced[columnName] || used[columnName] {
+			continue
+		}
+
+		candidates = append(candidates, columnName)
+	}
+
+	return candidates
+}
+
+// reads the transformed values of columns, nulls are read as empty strings
+// rows with invalid transforms are left out, as are repeated keys of derived tables
+func (table *Table) readValues(basePath string, columns []string) ([][]string, error) {
+	reader, err := table.openRows(basePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	fields, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make([]string, len(fields))
+	for idx, header := range fields {
+		columnName, ok := table.headerColumn(header)
+		if !ok {
+			return nil, fmt.Errorf("%s column not found in %s table schema", headerLabel(header), table.TableName)
+		}
+		headers[idx] = columnName
+	}
+	headers = append(headers, table.computedColumns()...)
+
+	indexes := make([]int, len(columns))
+	for idx, columnName := range columns {
+		indexes[idx] = slices.Index(headers, columnName)
+	}
+
+	keyIdx := slices.Index(headers, table.PrimaryKey)
+	seen := map[string]bool{}
+
+	rows := [][]string{}
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row, err := table.transformRow(headers, fields)
+		if err != nil {
+			continue
+		}
+
+		if table.DerivedFrom != "" && keyIdx != -1 {
+			key := strings.TrimSpace(row[keyIdx])
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+
+		values := make([]string, len(columns))
+		for idx, columnName := range columns {
+			if indexes[idx] == -1 {
+				continue
+			}
+
+			value := strings.TrimSpace(row[indexes[idx]])
+			if column := table.Columns[columnName]; !column.parsing.isNull(value) {
+				values[idx] = value
+			}
+		}
+		rows = append(rows, values)
+	}
+}
+
+// returns the dimensions of the rows of parent, the ones nested in each dimension follow it
+// a key repeated across rows on average determines the candidates whose value is the same in all of its rows
+// keys with more distinct values are tried first, so that nested dimensions are extracted from their parents
+// among keys with as many values, names like college_name are preferred
+func findDimensions(parent string, columns []string, rows [][]string, candidates []string, taken map[string]bool) []dimension {
+	distinct, nonNull := map[string]int{}, map[string]int{}
+	for _, columnName := range candidates {
+		idx := slices.Index(columns, columnName)
+		values := map[string]bool{}
+		for _, row := range rows {
+			if row[idx] != "" {
+				values[row[idx]] = true
+				nonNull[columnName]++
+			}
+		}
+		distinct[columnName] = len(values)
+	}
+
+	keys := slices.DeleteFunc(slices.Clone(candidates), func(columnName string) bool {
+		return distinct[columnName] < 2 || distinct[columnName]*2 > nonNull[columnName]
+	})
+	slices.SortStableFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(distinct[b], distinct[a]), cmp.Compare(suffixRank(a), suffixRank(b)))
+	})
+
+	dimensions := []dimension{}
+	used := map[string]bool{}
+
+	for _, key := range keys {
+		if used[key] {
+			continue
+		}
+
+		keyIdx := slices.Index(columns, key)
+		dependents := []string{}
+		for _, columnName := range candidates {
+			if columnName != key && !used[columnName] && distinct[columnName] > 1 && determines(rows, keyIdx, slices.Index(columns, columnName)) {
+				dependents = append(dependents, columnName)
+			}
+		}
+
+		if len(dependents) == 0 {
+			continue
+		}
+
+		used[key] = true
+		for _, columnName := range dependents {
+			used[columnName] = true
+		}
+
+		name := dimensionName(key, taken)
+		taken[name] = true
+		dimensions = append(dimensions, dimension{parent: parent, name: name, key: key, columns: dependents})
+
+		// a row per key value
+		dimensionColumns := append([]string{key}, dependents...)
+		dimensionRows := [][]string{}
+		seen := map[string]bool{}
+		for _, row := range rows {
+			if row[keyIdx] == "" || seen[row[keyIdx]] {
+				continue
+			}
+			seen[row[keyIdx]] = true
+
+			values := make([]string, len(dimensionColumns))
+			for idx, columnName := range dimensionColumns {
+				values[idx] = row[slices.Index(columns, columnName)]
+			}
+			dimensionRows = append(dimensionRows, values)
+		}
+
+		dimensions = append(dimensions, findDimensions(name, dimensionColumns, dimensionRows, dependents, taken)...)
+	}
+
+	return dimensions
+}
+
+// true if the value at keyIdx determines the value at idx, values without a key should be null
+func determines(rows [][]string, keyIdx, idx int) bool {
+	values := map[string]string{}
+
+	for _, row := range rows {
+		key, value := row[keyIdx], row[idx]
+		if key == "" {
+			if value != "" {
+				return false
+			}
+			continue
+		}
+
+		if previous, ok := values[key]; ok && previous != value {
+			return false
+		}
+		values[key] = value
+	}
+
+	return true
+}
+
+// returns key without its dimension suffix, e.g. college_name -> college
+func dimensionBase(key string) string {
+	for _, suffix := range dimensionSuffixes {
+		if trimmed := strings.TrimSuffix(strings.ToLower(key), suffix); trimmed != strings.ToLower(key) && trimmed != "" {
+			return key[:len(trimmed)]
+		}
+	}
+	return key
+}
+
+// 0 for keys with a dimension suffix, 1 otherwise
+func suffixRank(key string) int {
+	if dimensionBase(key) != key {
+		return 0
+	}
+	return 1
+}
+
+// returns the table name of the dimension of key, numbered if taken
+func dimensionName(key string, taken map[string]bool) string {
+	base := dimensionBase(key)
+	name := base
+	for idx := 2; taken[name]; idx++ {
+		name = fmt.Sprintf("%s_%d", base, idx)
+	}
+	return name
+}
+
+// adds the dimension table derived from its parent, the parent keeps the key as a foreign key to it
+func (dbSchema *DB) extractDimension(dimension dimension) {
+	parent := dbSchema.Tables[dimension.parent]
+
+	keyColumn := parent.Columns[dimension.key]
+	keyColumn.Unique, keyColumn.NotNull = true, true
+
+	columns := map[string]Column{dimension.key: keyColumn}
+	for _, columnName := range dimension.columns {
+		columns[columnName] = parent.Columns[columnName]
+		delete(parent.Columns, columnName)
+	}
+
+	foreignKey := parent.Columns[dimension.key]
+	foreignKey.ForeignTable, foreignKey.ForeignField = dimension.name, dimension.key
+	foreignKey.OnUpdate, foreignKey.OnDelete = "CASCADE", "CASCADE"
+	parent.Columns[dimension.key] = foreignKey
+
+	dbSchema.Tables[dimension.parent] = parent
+	dbSchema.Tables[dimension.name] = Table{
+		TableName:   dimension.name,
+		Label:       dimension.name,
+		DerivedFrom: dimension.parent,
+		PrimaryKey:  dimension.key,
+		Columns:     columns,
+	}
+}
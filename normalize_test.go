@@ -0,0 +1,140 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func Test_findDimensions(t *testing.T) {
+	columns := []string{"college_city", "college_name", "course_fee", "course_name", "grade"}
+	rows := [][]string{
+		{"Delhi", "IIT", "1000", "BTech", "A"},
+		{"Delhi", "IIT", "2000", "MTech", "B"},
+		{"Delhi", "IIT", "1000", "BTech", "C"},
+		{"Delhi", "IIT", "2000", "MTech", "A"},
+		{"Delhi", "DU", "500", "BSc", "B"},
+		{"Delhi", "DU", "500", "BSc", "C"},
+		{"Varanasi", "BHU", "400", "BA", "A"},
+		{"Varanasi", "BHU", "400", "BA", "B"},
+		{"Varanasi", "BHU", "400", "BA", "B"},
+		{"Varanasi", "BHU", "400", "BA", "C"},
+		{"Delhi", "IIT", "3000", "PhD", "A"},
+		{"Delhi", "IIT", "3000", "PhD", "B"},
+		{"Delhi", "DU", "700", "MSc", "A"},
+		{"Delhi", "DU", "700", "MSc", "C"},
+		{"", "", "", "", "A"},
+	}
+
+	want := []dimension{
+		{parent: "students", name: "course", key: "course_name", columns: []string{"college_city", "college_name", "course_fee"}},
+		{parent: "course", name: "college", key: "college_name", columns: []string{"college_city"}},
+	}
+
+	// courses determine their fees & vice versa, course_name is preferred by its suffix
+	got := findDimensions("students", columns, rows, columns, map[string]bool{"students": true})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findDimensions() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDB_dimensionCandidates(t *testing.T) {
+	table := Table{
+		TableName:  "students",
+		PrimaryKey: "roll",
+		Checks:     map[string]string{"named": "college_name != ''"},
+		Columns: map[string]Column{
+			"roll":         {ColumnName: "roll", DataType: "integer"},
+			"name":         {ColumnName: "name", DataType: "text"},
+			"college_name": {ColumnName: "college_name", DataType: "text"},
+			"city":         {ColumnName: "city", DataType: "text"},
+			"city_code":    {ColumnName: "city_code", DataType: "text", Expr: "upper(city)"},
+			"course_id":    {ColumnName: "course_id", DataType: "integer", ForeignTable: "courses", ForeignField: "id"},
+		},
+	}
+	if err := table.setChecks(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.setTransforms(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the check contains name within college_name, only the columns it references are left out
+	dbSchema := DB{Tables: map[string]Table{"students": table}}
+	want := []string{"name"}
+	if got := dbSchema.dimensionCandidates(&table); !reflect.DeepEqual(got, want) {
+		t.Errorf("DB.dimensionCandidates() = %v, want %v", got, want)
+	}
+}
+
+func Test_determines(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]string
+		want bool
+	}{
+		{name: "same values", rows: [][]string{{"a", "1"}, {"b", "2"}, {"a", "1"}}, want: true},
+		{name: "different values", rows: [][]string{{"a", "1"}, {"a", "2"}}, want: false},
+		{name: "null key with null value", rows: [][]string{{"a", "1"}, {"", ""}}, want: true},
+		{name: "null key with value", rows: [][]string{{"a", "1"}, {"", "1"}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := determines(tt.rows, 0, 1); got != tt.want {
+				t.Errorf("determines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dimensionName(t *testing.T) {
+	taken := map[string]bool{"college": true, "college_2": true}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "course_name", want: "course"},
+		{key: "Dept_ID", want: "Dept"},
+		{key: "college_name", want: "college_3"},
+		{key: "city", want: "city"},
+		{key: "_id", want: "_id"},
+	}
+	for _, tt := range tests {
+		if got := dimensionName(tt.key, taken); got != tt.want {
+			t.Errorf("dimensionName(%s) = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
+
+func Test_setDerivedTables(t *testing.T) {
+	dbSchema := DB{Tables: map[string]Table{
+		"students": {TableName: "students", FileName: "students.csv", PrimaryKey: "roll", Columns: map[string]Column{"roll": {}, "course_name": {}}},
+		"course":   {TableName: "course", DerivedFrom: "students", PrimaryKey: "course_name", Columns: map[string]Column{"course_name": {}, "college_name": {}}},
+		"college":  {TableName: "college", DerivedFrom: "course", PrimaryKey: "college_name", Columns: map[string]Column{"college_name": {}, "college_city": {}}},
+	}}
+
+	if err := dbSchema.setDerivedTables(); err != nil {
+		t.Fatalf("setDerivedTables() error = %v", err)
+	}
+
+	if college := dbSchema.Tables["college"]; college.FileName != "students.csv" {
+		t.Errorf("setDerivedTables() college fileName = %s, want students.csv", college.FileName)
+	}
+
+	extracted := map[string][]string{"students": {"college_city", "college_name"}, "course": {"college_city", "roll"}, "college": {"course_name", "roll"}}
+	for tableName, want := range extracted {
+		got := []string{}
+		for columnName := range dbSchema.Tables[tableName].extracted {
+			got = append(got, columnName)
+		}
+		slices.Sort(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("setDerivedTables() %s extracted = %v, want %v", tableName, got, want)
+		}
+	}
+
+	dbSchema.Tables["students"] = Table{TableName: "students", DerivedFrom: "college"}
+	if err := dbSchema.setDerivedTables(); err == nil {
+		t.Errorf("setDerivedTables() expected an error for cycle")
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ParseOptions of the inferred schema
+func defaultParseOptions() ParseOptions {
+	thousandsSeparator := ","
+	return ParseOptions{
+		NullTokens:         []string{"NA", "N/A", "-", "null"},
+		TrueValues:         []string{"yes", "y"},
+		FalseValues:        []string{"no", "n"},
+		DecimalSeparator:   ".",
+		ThousandsSeparator: &thousandsSeparator,
+		StripSymbols:       []string{"$", "€", "£", "₹", "%"},
+	}
+}
+
+var validDecimalSeparators = []string{".", ","}
+var validThousandsSeparators = []string{"", ",", ".", " ", "'", "_"}
+
+// returns the options overridden by the non-nil fields of column options, an empty thousandsSeparator drops the project one
+func (options ParseOptions) merge(override *ParseOptions) ParseOptions {
+	if override == nil {
+		return options
+	}
+
+	if override.NullTokens != nil {
+		options.NullTokens = override.NullTokens
+	}
+	if override.TrueValues != nil {
+		options.TrueValues = override.TrueValues
+	}
+	if override.FalseValues != nil {
+		options.FalseValues = override.FalseValues
+	}
+	if override.DecimalSeparator != "" {
+		options.DecimalSeparator = override.DecimalSeparator
+	}
+	if override.ThousandsSeparator != nil {
+		options.ThousandsSeparator = override.ThousandsSeparator
+	}
+	if override.StripSymbols != nil {
+		options.StripSymbols = override.StripSymbols
+	}
+
+	return options
+}
+
+// validates the options & compiles the number regex
+func (options *ParseOptions) validate() error {
+	if options.DecimalSeparator == "" {
+		options.DecimalSeparator = "."
+	}
+
+	if !slices.Contains(validDecimalSeparators, options.DecimalSeparator) {
+		return fmt.Errorf("invalid decimalSeparator %s, should be one of %v", options.DecimalSeparator, validDecimalSeparators)
+	}
+
+	thousandsSeparator := options.thousandsSeparator()
+	if !slices.Contains(validThousandsSeparators, thousandsSeparator) {
+		return fmt.Errorf("invalid thousandsSeparator %s, should be one of %q", thousandsSeparator, validThousandsSeparators)
+	}
+
+	if options.DecimalSeparator == thousandsSeparator {
+		return errors.New("decimalSeparator & thousandsSeparator can't be same")
+	}
+
+	for _, token := range options.TrueValues {
+		if containsFold(options.FalseValues, token) {
+			return fmt.Errorf("%s is both true & false value", token)
+		}
+	}
+
+	for _, token := range options.NullTokens {
+		if strings.TrimSpace(token) == "" {
+			return errors.New("empty null token")
+		}
+		if containsFold(options.TrueValues, token) || containsFold(options.FalseValues, token) {
+			return fmt.Errorf("%s is both null token & boolean value", token)
+		}
+	}
+
+	decimal := regexp.QuoteMeta(options.DecimalSeparator)
+	integer := `\d+`
+	if thousandsSeparator != "" {
+		integer = fmt.Sprintf(`(?:\d{1,3}(?:%s\d{3})+|\d+)`, regexp.QuoteMeta(thousandsSeparator))
+	}
+	options.number = regexp.MustCompile(fmt.Sprintf(`^[-+]?%s(?:%s\d+)?$`, integer, decimal))
+
+	return nil
+}
+
+// returns the thousands separator, empty if not set
+func (options *ParseOptions) thousandsSeparator() string {
+	if options.ThousandsSeparator == nil {
+		return ""
+	}
+	return *options.ThousandsSeparator
+}
+
+func containsFold(tokens []string, value string) bool {
+	return slices.ContainsFunc(tokens, func(token string) bool {
+		return strings.EqualFold(strings.TrimSpace(token), value)
+	})
+}
+
+func (options *ParseOptions) isNull(value string) bool {
+	return containsFold(options.NullTokens, strings.TrimSpace(value))
+}
+
+// converts true/false spellings into true & false, other values are returned as is
+func (options *ParseOptions) normalizeBool(value string) string {
+	if containsFold(options.TrueValues, value) {
+		return "true"
+	}
+	if containsFold(options.FalseValues, value) {
+		return "false"
+	}
+	return value
+}
+
+// strips symbols & separators of locale numbers, e.g. $1,234.50 -> 1234.50
+// values which aren't numbers even after stripping are returned as is
+func (options *ParseOptions) normalizeNumber(value string) string {
+	if options.number == nil {
+		return value
+	}
+
+	stripped := value
+	for _, symbol := range options.StripSymbols {
+		if symbol != "" {
+			stripped = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(stripped, symbol), symbol))
+		}
+	}
+
+	// -$5 & $-5 are both allowed
+	if sign := stripped[:min(1, len(stripped))]; sign == "-" || sign == "+" {
+		rest := strings.TrimSpace(stripped[1:])
+		for _, symbol := range options.StripSymbols {
+			if symbol != "" {
+				rest = strings.TrimSpace(strings.TrimPrefix(rest, symbol))
+			}
+		}
+		stripped = sign + rest
+	}
+
+	if !options.number.MatchString(stripped) {
+		return value
+	}
+
+	if thousandsSeparator := options.thousandsSeparator(); thousandsSeparator != "" {
+		stripped = strings.ReplaceAll(stripped, thousandsSeparator, "")
+	}
+
+	return strings.Replace(stripped, options.DecimalSeparator, ".", 1)
+}
+
+// normalizes a value for type inference, bool indicates a null value
+func (options *ParseOptions) normalize(value string) (string, bool) {
+	if options.isNull(value) {
+		return "", true
+	}
+
+	if normalized := options.normalizeBool(value); normalized != value {
+		return normalized, false
+	}
+
+	return options.normalizeNumber(value), false
+}
+
+// normalizes a CSV value of the column datatype (non-array)
+func (column *Column) normalizeInput(value string, datatype string) string {
+	options := &column.parsing
+
+	switch {
+	case datatype == "boolean":
+		return options.normalizeBool(value)
+	case isIntegerType(datatype), datatype == "real", baseType(datatype) == "numeric":
+		return options.normalizeNumber(value)
+	}
+
+	return value
+}
+
+// resolves the parse options of column from project options
+func (column *Column) setParseOptions(options ParseOptions) error {
+	column.parsing = options.merge(column.Parsing)
+	return column.parsing.validate()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOptions_normalize(t *testing.T) {
+	dot := "."
+	european := ParseOptions{DecimalSeparator: ",", ThousandsSeparator: &dot, StripSymbols: []string{"€"}}
+
+	tests := []struct {
+		name     string
+		options  ParseOptions
+		value    string
+		want     string
+		wantNull bool
+	}{
+		{name: "null token", options: defaultParseOptions(), value: "n/a", wantNull: true},
+		{name: "true spelling", options: defaultParseOptions(), value: "Yes", want: "true"},
+		{name: "false spelling", options: defaultParseOptions(), value: "N", want: "false"},
+		{name: "currency & thousands", options: defaultParseOptions(), value: "$1,234.50", want: "1234.50"},
+		{name: "negative currency", options: defaultParseOptions(), value: "-$5", want: "-5"},
+		{name: "percent", options: defaultParseOptions(), value: "12.5%", want: "12.5"},
+		{name: "misplaced separator", options: defaultParseOptions(), value: "1,23", want: "1,23"},
+		{name: "date untouched", options: defaultParseOptions(), value: "01.07.2024", want: "01.07.2024"},
+		{name: "european", options: european, value: "€ 1.234,56", want: "1234.56"},
+		{name: "no options", options: ParseOptions{}, value: "NA", want: "NA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			if err := options.validate(); err != nil {
+				t.Fatalf("ParseOptions.validate() error = %v", err)
+			}
+			got, isNull := options.normalize(tt.value)
+			if got != tt.want || isNull != tt.wantNull {
+				t.Errorf("ParseOptions.normalize() = %v %v, want %v %v", got, isNull, tt.want, tt.wantNull)
+			}
+		})
+	}
+}
+
+func TestParseOptions_validate(t *testing.T) {
+	comma := ","
+	tests := []struct {
+		name    string
+		options ParseOptions
+		wantErr bool
+	}{
+		{name: "default", options: defaultParseOptions()},
+		{name: "same separators", options: ParseOptions{DecimalSeparator: ",", ThousandsSeparator: &comma}, wantErr: true},
+		{name: "invalid decimal", options: ParseOptions{DecimalSeparator: ";"}, wantErr: true},
+		{name: "true & false", options: ParseOptions{TrueValues: []string{"y"}, FalseValues: []string{"Y"}}, wantErr: true},
+		{name: "null & boolean", options: ParseOptions{NullTokens: []string{"no"}, FalseValues: []string{"no"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.options.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ParseOptions.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestColumn_validateValueByConstraints_parseOptions(t *testing.T) {
+	none := ""
+	tests := []struct {
+		name     string
+		column   Column
+		override *ParseOptions
+		value    string
+		want     any
+		wantErr  bool
+	}{
+		{name: "null token", column: Column{DataType: "text"}, value: "NA", want: nil},
+		{name: "null token of not null column", column: Column{DataType: "integer", NotNull: true}, value: "-", wantErr: true},
+		{name: "null tokens overridden", column: Column{DataType: "text"}, override: &ParseOptions{NullTokens: []string{}}, value: "NA", want: "NA"},
+		{name: "boolean", column: Column{DataType: "boolean"}, value: "yes", want: true},
+		{name: "numeric", column: Column{DataType: "numeric(10,2)"}, value: "$1,234.5", want: "1234.50"},
+		{name: "thousands separator dropped", column: Column{DataType: "integer"}, override: &ParseOptions{ThousandsSeparator: &none}, value: "1,234", wantErr: true},
+		{name: "decimal comma", column: Column{DataType: "real"}, override: &ParseOptions{DecimalSeparator: ",", ThousandsSeparator: &none}, value: "1,5", want: 1.5},
+		{name: "real array", column: Column{DataType: "real[]"}, value: `["12%", 4]`, want: []any{12.0, 4.0}},
+		{name: "text untouched", column: Column{DataType: "text"}, value: "$5", want: "$5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column := tt.column
+			column.Parsing = tt.override
+			if err := column.setParseOptions(defaultParseOptions()); err != nil {
+				t.Fatalf("Column.setParseOptions() error = %v", err)
+			}
+			got, err := column.validateValueByConstraints(tt.value, true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Column.validateValueByConstraints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Column.validateValueByConstraints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// named formats for text columns, expressions are valid in both Go & Postgres regex
+var stringFormats = map[string]string{
+	"email": `^[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}$`,
+	"url":   `^https?://[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*(:[0-9]{1,5})?(/[^[:space:]]*)?$`,
+	"uuid":  `^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}$`,
+	"phone": `^\+?[1-9][0-9]{6,14}$`, // E.164
+	"slug":  `^[a-z0-9]+(-[a-z0-9]+)*$`,
+}
+
+type columnPattern struct {
+	Format string // empty for Column.Pattern
+	Regex  *regexp.Regexp
+}
+
+// validates & compiles the Format and Pattern of text, text[] columns
+// Pattern should stick to the syntax shared by Go & Postgres regex as it is checked in both
+func (column *Column) setPatternConstraint() error {
+	column.Format = strings.ToLower(strings.TrimSpace(column.Format))
+	column.patterns = nil
+
+	if column.Format == "" && column.Pattern == "" {
+		return nil
+	}
+
+	if elementType(column.DataType) != "text" {
+		return errors.New("pattern/format is supported only for text, text[] columns")
+	}
+
+	if column.enumKind == "type" {
+		return errors.New("pattern/format can't be used with enum types")
+	}
+
+	if column.Format != "" {
+		expr, ok := stringFormats[column.Format]
+		if !ok {
+			return fmt.Errorf("unsupported format %s, should be one of %v", column.Format, slices.Sorted(maps.Keys(stringFormats)))
+		}
+		column.patterns = append(column.patterns, columnPattern{Format: column.Format, Regex: regexp.MustCompile(expr)})
+	}
+
+	if column.Pattern != "" {
+		regex, err := regexp.Compile(column.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+		column.patterns = append(column.patterns, columnPattern{Regex: regex})
+	}
+
+	return nil
+}
+
+// checks if the provided value (non-array) matches the column format & pattern
+func (column *Column) validateValueByPattern(value any) error {
+	if value == nil || len(column.patterns) == 0 {
+		return nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return errors.New("failed to typecast to text")
+	}
+
+	for _, pattern := range column.patterns {
+		if pattern.Regex.MatchString(str) {
+			continue
+		}
+
+		if pattern.Format != "" {
+			return fmt.Errorf("%s isn't a valid %s", str, pattern.Format)
+		}
+		return fmt.Errorf("%s doesn't match the pattern %s", str, pattern.Regex)
+	}
+
+	return nil
+}
+
+// SQL regex match conditions of the column patterns
+func templatePatternConditions(column Column, target string) []string {
+	conditions := []string{}
+	for _, pattern := range column.patterns {
+		conditions = append(conditions, fmt.Sprintf("%s ~ %s", target, templateRegex(pattern.Regex)))
+	}
+	return conditions
+}
+
+// patterns argument of validate_text_arr
+func templatePatternArray(column Column) string {
+	if len(column.patterns) == 0 {
+		return "NULL"
+	}
+
+	values := []string{}
+	for _, pattern := range column.patterns {
+		values = append(values, templateRegex(pattern.Regex))
+	}
+
+	return "array[" + strings.Join(values, ", ") + "]::text[]"
+}
+
+func templateRegex(regex *regexp.Regexp) string {
+	return "'" + strings.ReplaceAll(regex.String(), "'", "''") + "'"
+}
+
+// go literal of the column patterns used in generated app models
+func templateAppPatterns(column Column) string {
+	if len(column.patterns) == 0 {
+		return ""
+	}
+
+	values := []string{}
+	for _, pattern := range column.patterns {
+		values = append(values, fmt.Sprintf("{Format: %q, Regex: regexp.MustCompile(%q)}", pattern.Format, pattern.Regex.String()))
+	}
+
+	return "[]Pattern{" + strings.Join(values, ", ") + "}"
+}
@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+func TestColumn_setPatternConstraint(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  Column
+		value   any
+		wantErr bool
+		wantVal bool // value satisfies the patterns
+	}{
+		{
+			name:    "email format",
+			column:  Column{DataType: "text", Format: " Email "},
+			value:   "user@example.com",
+			wantVal: true,
+		},
+		{
+			name:    "invalid email",
+			column:  Column{DataType: "text", Format: "email"},
+			value:   "user@example",
+			wantVal: false,
+		},
+		{
+			name:    "uuid array",
+			column:  Column{DataType: "text[]", Format: "uuid"},
+			value:   "0190b3c4-7f2e-7a21-9d4b-6c1e2f3a4b5c",
+			wantVal: true,
+		},
+		{
+			name:    "format & pattern",
+			column:  Column{DataType: "text", Format: "slug", Pattern: "^.{1,5}$"},
+			value:   "too-long",
+			wantVal: false,
+		},
+		{
+			name:    "unsupported format",
+			column:  Column{DataType: "text", Format: "ipv4"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid pattern",
+			column:  Column{DataType: "text", Pattern: "[a-z"},
+			wantErr: true,
+		},
+		{
+			name:    "non text column",
+			column:  Column{DataType: "integer", Format: "phone"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.column.setPatternConstraint()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Column.setPatternConstraint() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if err := tt.column.validateValueByPattern(tt.value); (err == nil) != tt.wantVal {
+				t.Errorf("Column.validateValueByPattern() error = %v, wantVal %v", err, tt.wantVal)
+			}
+		})
+	}
+}
+
+func Test_templateCheckConstraints_patterns(t *testing.T) {
+	column := Column{DataType: "text", Pattern: "^it's$"}
+	if err := column.setPatternConstraint(); err != nil {
+		t.Fatalf("Column.setPatternConstraint() error = %v", err)
+	}
+
+	want := ` CHECK ( "col" ~ '^it''s$' )`
+	if got := templateCheckConstraints(column, "col"); got != want {
+		t.Errorf("templateCheckConstraints() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+var profileFormats = []string{"md", "html"}
+
+// upper bounds of string length buckets, the last bucket holds longer strings
+var lengthBuckets = []int{0, 10, 50, 255}
+
+type tableProfile struct {
+	TableName   string
+	Label       string
+	Source      string
+	Rows        int
+	Columns     []*columnProfile // ordered as per headers
+	Keys        []string         // columns with unique non-null values
+	ForeignKeys []foreignKeyCandidate
+}
+
+type columnProfile struct {
+	ColumnName   string
+	Label        string
+	DataType     string       // inferred type
+	Candidates   []valueCount // detected types of values, the competing ones besides the inferred type
+	Nulls        int          // null tokens
+	Empties      int          // empty strings
+	Distinct     int
+	TopValues    []valueCount
+	Min          string // numbers & datetimes only
+	Max          string
+	Mean         string // numbers only
+	MeanLength   string
+	Lengths      []valueCount // string lengths, bucketed
+	ArrayLengths []valueCount // lengths of the first dimension, ordered by length
+	values       map[string]int
+	min, max     float64
+	minTime      time.Time
+	maxTime      time.Time
+	sum          float64
+	numbers      int
+	lengthSum    int
+	column       Column
+}
+
+type valueCount struct {
+	Value string
+	Count int
+}
+
+type foreignKeyCandidate struct {
+	Column       string
+	ForeignTable string
+	ForeignField string
+}
+
+// profiles the sources in the data directory & writes profile.md or profile.html, returns the report path
+// parse & naming options of an existing schema.json are used
+func generateProfile(format string, top int) (string, error) {
+	if !slices.Contains(profileFormats, format) {
+		return "", fmt.Errorf("invalid format %s, should be one of %v", format, profileFormats)
+	}
+
+	if top < 1 {
+		return "", errors.New("top should be at least 1")
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	dataPath := filepath.Join(basePath, "data")
+
+	existingSchema := DB{Parsing: defaultParseOptions(), Naming: defaultNamingOptions()}
+	if err := readJsonFile(filepath.Join(dataPath, "schema.json"), &existingSchema); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read existing schema: %v", err)
+	}
+
+	if err := existingSchema.Parsing.validate(); err != nil {
+		return "", err
+	}
+
+	if err := existingSchema.Naming.validate(); err != nil {
+		return "", fmt.Errorf("invalid naming options: %v", err)
+	}
+
+	if err := existingSchema.requireSources("profile"); err != nil {
+		return "", err
+	}
+
+	dbSchema, err := inferSchema(dataPath, &existingSchema.Parsing, existingSchema.Naming, existingSchema.Tables)
+	if err != nil {
+		return "", err
+	}
+
+	profiles := []*tableProfile{}
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		table := dbSchema.Tables[tableName]
+
+		reader, err := openSource(dataPath, &table)
+		if err != nil {
+			return "", err
+		}
+
+		profile, err := profileTable(reader, &table, &existingSchema.Parsing, top)
+		reader.Close()
+		if err != nil {
+			return "", fmt.Errorf("error while profiling %s table: %v", tableName, err)
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	setForeignKeyCandidates(profiles)
+
+	var buffer bytes.Buffer
+	templatePath := filepath.Join(basePath, "templates", "profile."+format+".tmpl")
+	funcs := map[string]any{"join": strings.Join}
+
+	if format == "html" {
+		tmpl, err := htmlTemplate.New(filepath.Base(templatePath)).Funcs(funcs).ParseFiles(templatePath)
+		if err != nil {
+			return "", err
+		}
+		err = tmpl.Execute(&buffer, profiles)
+	} else {
+		tmpl, err := template.New(filepath.Base(templatePath)).Funcs(funcs).ParseFiles(templatePath)
+		if err != nil {
+			return "", err
+		}
+		err = tmpl.Execute(&buffer, profiles)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(dataPath, "profile."+format)
+	if err := writeFile(filePath, &buffer); err != nil {
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+// reads the source of an inferred table & returns the statistics of its columns
+func profileTable(reader sourceReader, table *Table, options *ParseOptions, top int) (*tableProfile, error) {
+	profile := &tableProfile{TableName: table.TableName, Label: displayLabel(table.Label, table.TableName), Source: table.sourceName()}
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, header := range headers {
+		columnName, ok := table.headerColumn(header)
+		if !ok {
+			return nil, fmt.Errorf("%s column not found in %s table", headerLabel(header), table.TableName)
+		}
+
+		column := table.Columns[columnName]
+		profile.Columns = append(profile.Columns, &columnProfile{
+			ColumnName: columnName,
+			Label:      displayLabel(column.Label, columnName),
+			DataType:   column.DataType,
+			values:     map[string]int{},
+			column:     column,
+		})
+	}
+
+	candidates := make([]map[string]int, len(headers))
+	lengths := make([]map[string]int, len(headers))
+	arrayLengths := make([]map[int]int, len(headers))
+	for idx := range headers {
+		candidates[idx], lengths[idx], arrayLengths[idx] = map[string]int{}, map[string]int{}, map[int]int{}
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		profile.Rows++
+
+		for idx, value := range row {
+			columnProfile := profile.Columns[idx]
+			value = strings.TrimSpace(value)
+
+			if value == "" {
+				columnProfile.Empties++
+				continue
+			}
+
+			normalized, isNull := options.normalize(value)
+			if isNull {
+				columnProfile.Nulls++
+				continue
+			}
+
+			columnProfile.values[normalized]++
+			candidates[idx][cmp.Or(detectDataType(normalized), "empty array")]++
+			lengths[idx][lengthBucket(utf8.RuneCountInString(value))]++
+			columnProfile.lengthSum += utf8.RuneCountInString(value)
+
+			if arrayDims(columnProfile.DataType) > 0 {
+				if arr, err := parseArray(normalized, columnProfile.DataType); err == nil {
+					arrayLengths[idx][len(arr)]++
+				}
+				continue
+			}
+
+			columnProfile.addRange(normalized)
+		}
+	}
+
+	for idx, columnProfile := range profile.Columns {
+		nonNull := profile.Rows - columnProfile.Nulls - columnProfile.Empties
+
+		columnProfile.Distinct = len(columnProfile.values)
+		columnProfile.TopValues = topCounts(columnProfile.values, top)
+		columnProfile.Candidates = topCounts(candidates[idx], len(candidates[idx]))
+		columnProfile.Lengths = bucketCounts(lengths[idx])
+		columnProfile.ArrayLengths = lengthCounts(arrayLengths[idx])
+
+		if nonNull > 0 {
+			columnProfile.MeanLength = strconv.FormatFloat(float64(columnProfile.lengthSum)/float64(nonNull), 'f', 2, 64)
+		}
+
+		if columnProfile.numbers > 0 {
+			columnProfile.Min = strconv.FormatFloat(columnProfile.min, 'f', -1, 64)
+			columnProfile.Max = strconv.FormatFloat(columnProfile.max, 'f', -1, 64)
+			columnProfile.Mean = strconv.FormatFloat(columnProfile.sum/float64(columnProfile.numbers), 'f', 2, 64)
+		} else if !columnProfile.minTime.IsZero() {
+			layout := datetimeFormats[columnProfile.DataType]
+			columnProfile.Min, columnProfile.Max = columnProfile.minTime.Format(layout), columnProfile.maxTime.Format(layout)
+		}
+
+		if nonNull > 0 && columnProfile.Nulls+columnProfile.Empties == 0 && columnProfile.Distinct == nonNull {
+			profile.Keys = append(profile.Keys, columnProfile.ColumnName)
+		}
+	}
+
+	return profile, nil
+}
+
+// tracks the min, max & sum of numbers and the range of datetimes
+func (profile *columnProfile) addRange(value string) {
+	datatype := profile.DataType
+
+	if isIntegerType(datatype) || datatype == "real" || baseType(datatype) == "numeric" {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return
+		}
+
+		if profile.numbers == 0 {
+			profile.min, profile.max = number, number
+		}
+		profile.min, profile.max = math.Min(profile.min, number), math.Max(profile.max, number)
+		profile.sum += number
+		profile.numbers++
+		return
+	}
+
+	if isDatetimeType(datatype) {
+		parsed, err := parseDatetime(value, datatype, profile.column.InputFormat, time.UTC)
+		if err != nil {
+			return
+		}
+
+		if profile.minTime.IsZero() || parsed.Before(profile.minTime) {
+			profile.minTime = parsed
+		}
+		if profile.maxTime.IsZero() || parsed.After(profile.maxTime) {
+			profile.maxTime = parsed
+		}
+	}
+}
+
+// sets the columns whose values are all present in a candidate key of another column as foreign keys
+func setForeignKeyCandidates(profiles []*tableProfile) {
+	for _, profile := range profiles {
+		for _, columnProfile := range profile.Columns {
+			if columnProfile.Distinct == 0 {
+				continue
+			}
+
+			for _, foreign := range profiles {
+				for _, key := range foreign.Keys {
+					if foreign == profile && key == columnProfile.ColumnName {
+						continue
+					}
+
+					keyProfile := foreign.column(key)
+					if elementType(keyProfile.DataType) != columnProfile.DataType || !containsAllKeys(keyProfile.values, columnProfile.values) {
+						continue
+					}
+
+					profile.ForeignKeys = append(profile.ForeignKeys, foreignKeyCandidate{
+						Column:       columnProfile.ColumnName,
+						ForeignTable: foreign.TableName,
+						ForeignField: key,
+					})
+				}
+			}
+		}
+	}
+}
+
+func (profile *tableProfile) column(columnName string) *columnProfile {
+	for _, columnProfile := range profile.Columns {
+		if columnProfile.ColumnName == columnName {
+			return columnProfile
+		}
+	}
+	return nil
+}
+
+func containsAllKeys(set, subset map[string]int) bool {
+	if len(subset) > len(set) {
+		return false
+	}
+
+	for key := range subset {
+		if _, ok := set[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// returns the n most frequent values, ties are ordered by value
+func topCounts(counts map[string]int, n int) []valueCount {
+	result := make([]valueCount, 0, len(counts))
+	for value, count := range counts {
+		result = append(result, valueCount{Value: value, Count: count})
+	}
+
+	slices.SortFunc(result, func(a, b valueCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+	})
+
+	return result[:min(n, len(result))]
+}
+
+// returns the label of the length bucket, e.g. 11-50
+func lengthBucket(length int) string {
+	lower := 0
+	for _, upper := range lengthBuckets {
+		if length <= upper {
+			if lower == upper {
+				return strconv.Itoa(upper)
+			}
+			return fmt.Sprintf("%d-%d", lower, upper)
+		}
+		lower = upper + 1
+	}
+	return fmt.Sprintf("%d+", lower)
+}
+
+// returns the counts of length buckets in the order of buckets
+func bucketCounts(counts map[string]int) []valueCount {
+	result := []valueCount{}
+	for _, length := range append(slices.Clone(lengthBuckets), math.MaxInt) {
+		bucket := lengthBucket(length)
+		if count, ok := counts[bucket]; ok {
+			result = append(result, valueCount{Value: bucket, Count: count})
+		}
+	}
+	return result
+}
+
+// returns the counts of array lengths in the order of lengths
+func lengthCounts(counts map[int]int) []valueCount {
+	result := make([]valueCount, 0, len(counts))
+	for _, length := range slices.Sorted(maps.Keys(counts)) {
+		result = append(result, valueCount{Value: strconv.Itoa(length), Count: counts[length]})
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_profileTable(t *testing.T) {
+	table := &Table{
+		TableName: "students",
+		Columns: map[string]Column{
+			"id":     {ColumnName: "id", DataType: "integer"},
+			"name":   {ColumnName: "name", DataType: "text"},
+			"marks":  {ColumnName: "marks", DataType: "integer[]"},
+			"course": {ColumnName: "course", DataType: "integer"},
+		},
+	}
+	reader := &rowsReader{rows: [][]string{
+		{"id", "name", "marks", "course"},
+		{"1", "Asha", "[90, 80]", "10"},
+		{"2", "NULL", "[70]", "10"},
+		{"3", "", "[60, 50]", "x"},
+	}}
+	options := defaultParseOptions()
+
+	profile, err := profileTable(reader, table, &options, 1)
+	if err != nil {
+		t.Fatalf("profileTable() error = %v", err)
+	}
+
+	if profile.Rows != 3 || !reflect.DeepEqual(profile.Keys, []string{"id", "marks"}) {
+		t.Errorf("profileTable() rows = %d, keys = %v", profile.Rows, profile.Keys)
+	}
+
+	id, name, marks, course := profile.Columns[0], profile.Columns[1], profile.Columns[2], profile.Columns[3]
+
+	if id.Min != "1" || id.Max != "3" || id.Mean != "2.00" {
+		t.Errorf("id range = %s..%s, mean %s", id.Min, id.Max, id.Mean)
+	}
+
+	if name.Nulls != 1 || name.Empties != 1 || name.Distinct != 1 {
+		t.Errorf("name nulls = %d, empties = %d, distinct = %d", name.Nulls, name.Empties, name.Distinct)
+	}
+
+	if want := []valueCount{{Value: "1", Count: 1}, {Value: "2", Count: 2}}; !reflect.DeepEqual(marks.ArrayLengths, want) {
+		t.Errorf("marks array lengths = %v, want %v", marks.ArrayLengths, want)
+	}
+
+	if want := []valueCount{{Value: "integer", Count: 2}, {Value: "text", Count: 1}}; !reflect.DeepEqual(course.Candidates, want) {
+		t.Errorf("course candidates = %v, want %v", course.Candidates, want)
+	}
+
+	if want := []valueCount{{Value: "10", Count: 2}}; !reflect.DeepEqual(course.TopValues, want) {
+		t.Errorf("course top values = %v, want %v", course.TopValues, want)
+	}
+}
+
+func Test_setForeignKeyCandidates(t *testing.T) {
+	courses := &tableProfile{
+		TableName: "courses",
+		Columns:   []*columnProfile{{ColumnName: "id", DataType: "integer", Distinct: 2, values: map[string]int{"1": 1, "2": 1}}},
+		Keys:      []string{"id"},
+	}
+	students := &tableProfile{
+		TableName: "students",
+		Columns: []*columnProfile{
+			{ColumnName: "course", DataType: "integer", Distinct: 1, values: map[string]int{"2": 3}},
+			{ColumnName: "name", DataType: "text", Distinct: 1, values: map[string]int{"1": 1}},
+			{ColumnName: "courses", DataType: "integer[]", Distinct: 1, values: map[string]int{"[1]": 1}},
+		},
+	}
+
+	setForeignKeyCandidates([]*tableProfile{courses, students})
+
+	want := []foreignKeyCandidate{{Column: "course", ForeignTable: "courses", ForeignField: "id"}}
+	if !reflect.DeepEqual(students.ForeignKeys, want) || courses.ForeignKeys != nil {
+		t.Errorf("setForeignKeyCandidates() = %v, want %v", students.ForeignKeys, want)
+	}
+}
+
+func Test_lengthBucket(t *testing.T) {
+	tests := map[int]string{0: "0", 1: "1-10", 10: "1-10", 11: "11-50", 255: "51-255", 256: "256+"}
+	for length, want := range tests {
+		if got := lengthBucket(length); got != want {
+			t.Errorf("lengthBucket(%d) = %v, want %v", length, got, want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// rows read from the source of a table, written once the foreign values are validated
+type tableRows struct {
+	headers []string // column names in field order
+	labels  []string // headers of the source, written to the rejects file
+	rows    []insertRow
+	rejects []rejectedRow
+	total   int // data rows read
+	dropped int // duplicates dropped or merged by Table.Dedup
+}
+
+// a valid row of the INSERT statement
+type insertRow struct {
+	rowIdx   int
+	sql      string   // e.g. (1, 'name')
+	key      string   // primary key value used by foreign look ups, empty if none
+	fields   []string // source fields, kept for the rejects file only
+	rejected bool     // rejected after insertion due to a foreign value
+}
+
+type rejectedRow struct {
+	rowIdx int
+	reason string
+	fields []string
+}
+
+// parses an error budget, either a count (100) or a percentage of rows (0.5%), -1 for the unset one
+func parseErrorBudget(text string) (int, float64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return -1, -1, nil
+	}
+
+	if rate, ok := strings.CutSuffix(text, "%"); ok {
+		value, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || value < 0 || value > 100 {
+			return 0, 0, fmt.Errorf("invalid error budget %s, percentage should be between 0 and 100", text)
+		}
+		return -1, value, nil
+	}
+
+	count, err := strconv.Atoi(text)
+	if err != nil || count < 0 {
+		return 0, 0, fmt.Errorf("invalid error budget %s, should be a count or a percentage", text)
+	}
+
+	return count, -1, nil
+}
+
+// rejects an inserted row whose foreign value isn't found, returns false if it's already rejected
+// the primary key of the row is dropped so that the rows referring to it are rejected as well
+func (table *Table) rejectInserted(rowIdx int, reason string) bool {
+	rows := table.rows
+	idx, found := slices.BinarySearchFunc(rows.rows, rowIdx, func(row insertRow, rowIdx int) int {
+		return row.rowIdx - rowIdx
+	})
+	if !found || rows.rows[idx].rejected {
+		return false
+	}
+
+	row := &rows.rows[idx]
+	row.rejected = true
+	rows.rejects = append(rows.rejects, rejectedRow{rowIdx: rowIdx, reason: reason, fields: row.fields})
+
+	if row.key != "" {
+		delete(table.Columns[table.PrimaryKey].values, row.key)
+	}
+
+	return true
+}
+
+// writes the INSERT statement of the valid rows
+// with IfNotExists, rows whose primary key is generated don't conflict on re-runs, hence they're inserted only into empty tables
+func (table *Table) writeInsertion(writer *bytes.Buffer, options *SQLOptions) {
+	rows := table.rows
+	written := 0
+	guarded, tag := options.IfNotExists && !slices.Contains(rows.headers, table.PrimaryKey), ""
+	if guarded {
+		tag = table.insertionTag()
+	}
+
+	for _, row := range rows.rows {
+		if row.rejected {
+			continue
+		}
+
+		if written == 0 {
+			columnNames := append(slices.Clone(rows.headers), table.lineageHeaders()...)
+			headers := make([]string, len(columnNames))
+			for idx, columnName := range columnNames {
+				headers[idx] = fmt.Sprintf(`"%s"`, columnName)
+			}
+
+			fmt.Fprintf(writer, "-- DATA INSERTION \"%s\"\n", table.TableName)
+			if guarded {
+				fmt.Fprintf(writer, "DO %s\nBEGIN\nIF NOT EXISTS (SELECT 1 FROM \"%s\") THEN\n", tag, table.TableName)
+			}
+			fmt.Fprintf(writer, "INSERT INTO \"%s\" (%s)\nVALUES\n", table.TableName, strings.Join(headers, ", "))
+		} else {
+			writer.WriteString(",\n")
+		}
+
+		writer.WriteString(row.sql)
+		written++
+	}
+
+	if written > 0 {
+		if options.IfNotExists {
+			writer.WriteString("\nON CONFLICT DO NOTHING")
+		}
+		writer.WriteString(";\n")
+		if guarded {
+			fmt.Fprintf(writer, "END IF;\nEND\n%s;\n", tag)
+		}
+
+		writer.WriteString(templateSequenceReset(table, rows.headers))
+	}
+
+	writer.WriteString("\n")
+}
+
+// returns the dollar quote tag of the guarded insertion, which doesn't occur in the values of rows
+func (table *Table) insertionTag() string {
+	tag := "$insert$"
+	for idx := 1; slices.ContainsFunc(table.rows.rows, func(row insertRow) bool { return strings.Contains(row.sql, tag) }); idx++ {
+		tag = fmt.Sprintf("$insert_%d$", idx)
+	}
+	return tag
+}
+
+// writes the rejected rows of table to rejects/<table>.csv in basePath, stale files of tables without rejects are removed
+func (table *Table) writeRejects(basePath string) error {
+	filePath := filepath.Join(basePath, "rejects", table.TableName+".csv")
+	rows := table.rows
+
+	if len(rows.rejects) == 0 {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	slices.SortFunc(rows.rejects, func(a, b rejectedRow) int {
+		return a.rowIdx - b.rowIdx
+	})
+
+	writer := csv.NewWriter(file)
+	writer.Write(append([]string{"file", "row", "reason"}, rows.labels...))
+
+	for _, reject := range rows.rejects {
+		fileName, row := table.rowSource(reject.rowIdx)
+		writer.Write(append([]string{fileName, strconv.Itoa(row), reject.reason}, reject.fields...))
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// returns an error if the rejected rows of table exceed the error budget
+func (table *Table) checkErrorBudget(options *SQLOptions) error {
+	rejected, total := len(table.rows.rejects), table.rows.total
+
+	if options.MaxErrors >= 0 && rejected > options.MaxErrors {
+		return fmt.Errorf("%d of %d rows of %s table rejected, more than the budget of %d rows", rejected, total, table.TableName, options.MaxErrors)
+	}
+
+	if options.MaxErrorRate >= 0 && float64(rejected)*100 > options.MaxErrorRate*float64(total) {
+		return fmt.Errorf("%d of %d rows of %s table rejected, more than the budget of %g%%", rejected, total, table.TableName, options.MaxErrorRate)
+	}
+
+	return nil
+}
+
+// writes the rejects files of all the tables & returns the summary of rejected rows
+func (dbSchema *DB) writeRejects(options *SQLOptions) ([]string, error) {
+	report := []string{}
+
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		table := dbSchema.Tables[tableName]
+
+		if err := table.writeRejects(dbSchema.BasePath); err != nil {
+			return report, fmt.Errorf("error while writing rejects of %s table: %v", tableName, err)
+		}
+
+		if rejected := len(table.rows.rejects); rejected > 0 {
+			report = append(report, fmt.Sprintf("%d of %d rows of %s table rejected, see rejects/%s.csv", rejected, table.rows.total, tableName, tableName))
+		}
+	}
+
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		table := dbSchema.Tables[tableName]
+		if err := table.checkErrorBudget(options); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
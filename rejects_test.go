@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_parseErrorBudget(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantCount int
+		wantRate  float64
+		wantErr   bool
+	}{
+		{text: "", wantCount: -1, wantRate: -1},
+		{text: "100", wantCount: 100, wantRate: -1},
+		{text: "0", wantCount: 0, wantRate: -1},
+		{text: "0.5%", wantCount: -1, wantRate: 0.5},
+		{text: " 2 % ", wantCount: -1, wantRate: 2},
+		{text: "-1", wantErr: true},
+		{text: "150%", wantErr: true},
+		{text: "some", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			count, rate, err := parseErrorBudget(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseErrorBudget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if count != tt.wantCount || rate != tt.wantRate {
+				t.Errorf("parseErrorBudget() = %v, %v, want %v, %v", count, rate, tt.wantCount, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestTable_writeInsertion_ifNotExists(t *testing.T) {
+	tests := []struct {
+		name       string
+		primaryKey string
+		want       string
+	}{
+		{name: "primary key in source", primaryKey: "message", want: "INSERT INTO \"logs\" (\"message\")\nVALUES\n('a $insert$'),\n('b')\nON CONFLICT DO NOTHING;\n"},
+		{name: "generated key", want: "DO $insert_1$\nBEGIN\nIF NOT EXISTS (SELECT 1 FROM \"logs\") THEN\nINSERT INTO \"logs\" (\"message\")\nVALUES\n('a $insert$'),\n('b')\nON CONFLICT DO NOTHING;\nEND IF;\nEND\n$insert_1$;\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := Table{TableName: "logs", PrimaryKey: tt.primaryKey, Columns: map[string]Column{"message": {ColumnName: "message", DataType: "text"}}}
+			table.rows = &tableRows{headers: []string{"message"}, rows: []insertRow{{rowIdx: 2, sql: "('a $insert$')"}, {rowIdx: 3, sql: "('b')"}}}
+
+			var buffer bytes.Buffer
+			table.writeInsertion(&buffer, &SQLOptions{IfNotExists: true})
+			if got := strings.TrimPrefix(buffer.String(), "-- DATA INSERTION \"logs\"\n"); got != tt.want+"\n" {
+				t.Errorf("Table.writeInsertion() = %q, want %q", got, tt.want+"\n")
+			}
+		})
+	}
+}
+
+func Test_validateForeignValues(t *testing.T) {
+	newTables := func() map[string]Table {
+		return map[string]Table{
+			"courses": {
+				TableName:  "courses",
+				PrimaryKey: "id",
+				Columns:    map[string]Column{"id": {ColumnName: "id", Unique: true, values: map[string]bool{"1": true}}},
+				rows:       &tableRows{rows: []insertRow{{rowIdx: 2, key: "1"}}, total: 1},
+			},
+			"students": {
+				TableName:  "students",
+				PrimaryKey: "roll",
+				Columns: map[string]Column{
+					"roll":   {ColumnName: "roll", Unique: true, values: map[string]bool{"10": true, "11": true}},
+					"course": {ColumnName: "course", ForeignTable: "courses", ForeignField: "id", lookup: map[string][]int{"1": {2}, "9": {3}}},
+				},
+				rows: &tableRows{rows: []insertRow{{rowIdx: 2, key: "10"}, {rowIdx: 3, key: "11"}}, total: 2},
+			},
+			"marks": {
+				TableName: "marks",
+				Columns:   map[string]Column{"roll": {ColumnName: "roll", ForeignTable: "students", ForeignField: "roll", lookup: map[string][]int{"10": {2}, "11": {3, 4}}}},
+				rows:      &tableRows{rows: []insertRow{{rowIdx: 2}, {rowIdx: 3}, {rowIdx: 4}}, total: 3},
+			},
+		}
+	}
+
+	err := validateForeignValues(newTables(), &SQLOptions{})
+	if err == nil || !strings.Contains(err.Error(), "invalid value 9 for foreign key column course in students table") {
+		t.Errorf("validateForeignValues() error = %v", err)
+	}
+
+	tables := newTables()
+	if err := validateForeignValues(tables, &SQLOptions{Reject: true}); err != nil {
+		t.Fatalf("validateForeignValues() error = %v", err)
+	}
+
+	// rows referring to the rejected student are rejected as well
+	if got := len(tables["students"].rows.rejects); got != 1 {
+		t.Errorf("students rejects = %d, want 1", got)
+	}
+	if got := len(tables["marks"].rows.rejects); got != 2 {
+		t.Errorf("marks rejects = %d, want 2", got)
+	}
+
+	options := &SQLOptions{Reject: true, MaxErrors: -1, MaxErrorRate: 50}
+	students, marks := tables["students"], tables["marks"]
+	if err := students.checkErrorBudget(options); err != nil {
+		t.Errorf("checkErrorBudget() error = %v", err)
+	}
+	if err := marks.checkErrorBudget(options); err == nil {
+		t.Errorf("checkErrorBudget() should fail for 2 of 3 rows")
+	}
+
+	// a budget of 0 allows no rejects, unset budgets allow all
+	if err := students.checkErrorBudget(&SQLOptions{Reject: true, MaxErrors: 0, MaxErrorRate: -1}); err == nil {
+		t.Errorf("checkErrorBudget() should fail for a budget of 0 rows")
+	}
+	if err := students.checkErrorBudget(&SQLOptions{Reject: true, MaxErrors: -1, MaxErrorRate: 0}); err == nil {
+		t.Errorf("checkErrorBudget() should fail for a budget of 0%%")
+	}
+	if err := marks.checkErrorBudget(&SQLOptions{Reject: true, MaxErrors: -1, MaxErrorRate: -1}); err != nil {
+		t.Errorf("checkErrorBudget() error = %v, want no limit", err)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -20,16 +19,72 @@ type tableResponse struct {
 	err   error
 }
 
-func generateInititalSchema() error {
+// infers schema.json from the CSV files, merge keeps the manual edits of existing schema.json
+// returns the merge report, nil if merge is false
+func generateInititalSchema(merge bool) ([]string, error) {
 	BasePath, err := os.Getwd()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	dataPath := filepath.Join(BasePath, "data")
-	dirList, err := os.ReadDir(dataPath)
+	schemaFilePath := filepath.Join(dataPath, "schema.json")
+
+	// existing parse & naming options are used for re-inference
+	var existingSchema DB
+	parseOptions := defaultParseOptions()
+	naming := defaultNamingOptions()
+	if merge {
+		if err := readJsonFile(schemaFilePath, &existingSchema); err != nil {
+			return nil, fmt.Errorf("failed to read existing schema: %v", err)
+		}
+		parseOptions = existingSchema.Parsing
+		naming = existingSchema.Naming
+	}
+
+	if err := parseOptions.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := naming.validate(); err != nil {
+		return nil, fmt.Errorf("invalid naming options: %v", err)
+	}
+
+	// header-less files of existing tables keep their column names, transforms apply to the inferred types
+	var existingTables map[string]Table
+	if merge {
+		existingTables = existingSchema.Tables
+	}
+
+	dbSchema, err := inferSchema(dataPath, &parseOptions, naming, existingTables)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var report []string
+	if merge {
+		report = existingSchema.mergeInferredSchema(dbSchema)
+		dbSchema = existingSchema
+	}
+
+	if err := writeJsonFile(schemaFilePath, dbSchema); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// infers the schema of all the sources in dataPath
+// headers & transforms of existing tables are used as is, the first row of other sources is read as headers
+// sources of existing headerless tables without headers are named positionally
+func inferSchema(dataPath string, parseOptions *ParseOptions, naming NamingOptions, existingTables map[string]Table) (DB, error) {
+	sources, err := listSources(dataPath, naming, existingTables)
+	if err != nil {
+		return DB{}, err
+	}
+
+	if len(sources) == 0 {
+		return DB{}, fmt.Errorf("no sources found in %s", dataPath)
 	}
 
 	tableRespChannel := make(chan tableResponse, 5)
@@ -37,44 +92,43 @@ func generateInititalSchema() error {
 	var mutex sync.Mutex
 	primaryKeys := make(map[string]string, 5)
 
-	csvFiles := make(map[string]bool, 5)
+	tableNames := make(map[string]string, 5) // value: source name
 
-	for _, file := range dirList {
-		if file.IsDir() || !strings.HasSuffix(file.Name(), ".csv") {
-			continue
-		}
-
-		fileName := file.Name()
-		tableName := sanitize_db_label(strings.TrimSuffix(fileName, ".csv"))
+	for _, source := range sources {
+		tableName := source.TableName
 
 		if len(tableName) == 0 {
-			return errors.New("an unnamed csv file is found")
+			return DB{}, fmt.Errorf("an unnamed source %s is found", source.sourceName())
 		}
 
-		if _, ok := csvFiles[tableName]; ok {
-			message := fmt.Sprintf("table %s already exists", tableName)
-			return errors.New(message)
+		if sourceName, ok := tableNames[tableName]; ok {
+			return DB{}, fmt.Errorf("sources %s and %s both map to table %s", sourceName, source.sourceName(), tableName)
 		}
 
-		csvFiles[tableName] = true
+		tableNames[tableName] = source.sourceName()
+		source.naming = naming
 		mutex.Lock()
 		tablesCount += 1
 		mutex.Unlock()
 
-		filePath := filepath.Join(dataPath, fileName)
-		go createTableSchema(filePath, tableRespChannel)
+		var existing *Table
+		if table, ok := existingTables[tableName]; ok {
+			existing = &table
+			source.Headers, source.Headerless = table.Headers, table.Headerless
+		}
+
+		go createTableSchema(dataPath, source, existing, parseOptions, tableRespChannel)
 	}
 
-	dbSchema := DB{BasePath: dataPath, Tables: make(map[string]Table, 5)}
+	dbSchema := DB{BasePath: dataPath, Timezone: "UTC", Parsing: defaultParseOptions(), Naming: naming, Enums: map[string]Enum{}, Tables: make(map[string]Table, 5)}
 
 	// receive table schemas
 	for resp := range tableRespChannel {
 		if resp.err != nil {
-			return resp.err
+			return DB{}, resp.err
 		}
 
-		fileName := resp.table.FileName
-		tableName := sanitize_db_label(strings.TrimSuffix(fileName, ".csv"))
+		tableName := resp.table.TableName
 		dbSchema.Tables[tableName] = resp.table
 		key := resp.table.PrimaryKey + ":" + resp.table.Columns[resp.table.PrimaryKey].DataType
 		primaryKeys[key] = tableName
@@ -90,12 +144,7 @@ func generateInititalSchema() error {
 
 	dbSchema.setForeignKeys(primaryKeys)
 
-	schemaFilePath := filepath.Join(dataPath, "schema.json")
-	if err := writeJsonFile(schemaFilePath, dbSchema); err != nil {
-		return err
-	}
-
-	return nil
+	return dbSchema, nil
 }
 
 func (dbSchema *DB) setForeignKeys(primaryKeys map[string]string) {
@@ -116,19 +165,26 @@ func (dbSchema *DB) setForeignKeys(primaryKeys map[string]string) {
 	}
 }
 
-// Parses a CSV file and writes the response to channel
-func createTableSchema(filePath string, tableResponseChannel chan<- tableResponse) {
-	fileName := filepath.Base(filePath)
-	tableName := sanitize_db_label(strings.TrimSuffix(fileName, ".csv"))
-
-	table := Table{FileName: fileName, TableName: tableName}
+// Parses the source of table & writes the response to channel
+// the first row is read as headers unless the existing table is headerless or has headers
+func createTableSchema(basePath string, table Table, existing *Table, options *ParseOptions, tableResponseChannel chan<- tableResponse) {
+	fileName := table.sourceName()
 	table.Columns = make(map[string]Column, 20)
 	var mainError error
 
-	fp, err := os.Open(filePath)
+	if table.Headerless && len(table.Headers) == 0 {
+		if err := table.setPositionalHeaders(basePath); err != nil {
+			tableResponseChannel <- tableResponse{table: table, err: err}
+			return
+		}
+	}
+
+	reader, err := openSource(basePath, &table)
 
 	defer func() {
-		fp.Close()
+		if reader != nil {
+			reader.Close()
+		}
 		tableResponseChannel <- tableResponse{table: table, err: mainError}
 	}()
 
@@ -137,7 +193,6 @@ func createTableSchema(filePath string, tableResponseChannel chan<- tableRespons
 		return
 	}
 
-	reader := csv.NewReader(fp)
 	headers, err := reader.Read()
 
 	if err == io.EOF {
@@ -151,6 +206,7 @@ func createTableSchema(filePath string, tableResponseChannel chan<- tableRespons
 	}
 
 	// Initialize Columns
+	originals := make(map[string]string, len(headers)) // key: column name, value: header
 	for idx, header := range headers {
 		column := Column{}
 
@@ -164,18 +220,30 @@ func createTableSchema(filePath string, tableResponseChannel chan<- tableRespons
 
 		column.ColumnName = columnName
 
-		if _, ok := table.Columns[columnName]; ok {
-			message := fmt.Sprintf("column %s already exists in %s table", columnName, fileName)
-			mainError = errors.New(message)
+		// headers set for header-less files are column names, not original text
+		if len(table.Headers) == 0 {
+			column.Label = headerLabel(header)
+		}
+
+		if original, ok := originals[columnName]; ok {
+			mainError = fmt.Errorf("headers %q and %q of %s both map to column %s", original, header, fileName, columnName)
 			return
 		}
 
+		originals[columnName] = header
 		headers[idx] = columnName
 		table.Columns[columnName] = column
 	}
 
+	if existing != nil {
+		if headers, err = table.copyTransforms(existing, headers); err != nil {
+			mainError = fmt.Errorf("error in transforms of %s table: %v", table.TableName, err)
+			return
+		}
+	}
+
 	// Detect DataTypes by traversing rows
-	err = setColumnTypes(reader, &table, headers)
+	err = setColumnTypes(reader, &table, headers, options)
 	if err != nil {
 		message := fmt.Sprintf("error while parsing %s table data: %v", fileName, err)
 		mainError = errors.New(message)
@@ -183,8 +251,16 @@ func createTableSchema(filePath string, tableResponseChannel chan<- tableRespons
 	}
 }
 
-// It reads the entire CSV file and sets the column types
-func setColumnTypes(reader *csv.Reader, table *Table, headers []string) error {
+// It reads the entire source and sets the column types
+// values are normalized with the parse options before detection
+func setColumnTypes(reader sourceReader, table *Table, headers []string, options *ParseOptions) error {
+	layouts := make(map[string][]string, len(headers)) // datetime layouts parsing all the values
+	epochs := make(map[string]string, len(headers))    // unix, unix_ms or none
+
+	defer func() {
+		table.setInferredFormats(layouts, epochs)
+	}()
+
 	for {
 		row, err := reader.Read()
 		if err == io.EOF {
@@ -195,7 +271,11 @@ func setColumnTypes(reader *csv.Reader, table *Table, headers []string) error {
 			return err
 		}
 
-		count := len(headers)
+		if row, err = table.transformRow(headers, row); err != nil {
+			return err
+		}
+
+		count := len(headers) // columns left to detect, text ones with null values are done
 
 		// Traversing columns in the row
 		for j, value := range row {
@@ -204,17 +284,32 @@ func setColumnTypes(reader *csv.Reader, table *Table, headers []string) error {
 
 			existingType := column.DataType
 
-			value = strings.TrimSpace(value)
+			value, isNull := options.normalize(strings.TrimSpace(value))
 
-			if len(value) == 0 {
+			if len(value) == 0 || isNull {
+				if !column.hasNulls {
+					column.hasNulls = true
+					table.Columns[columnName] = column
+				}
+				if existingType == "text" {
+					count--
+				}
 				continue
 			}
 
 			if existingType == "text" {
-				count--
+				if column.hasNulls {
+					count--
+				}
 				continue
 			}
 
+			epoch := detectEpoch(value)
+			if previous, ok := epochs[columnName]; epoch == "" || (ok && previous != epoch) {
+				epoch = "none"
+			}
+			epochs[columnName] = epoch
+
 			// validate against existing type
 			if existingType != "" {
 				ok := validateAgainstExistingType(value, existingType)
@@ -223,25 +318,31 @@ func setColumnTypes(reader *csv.Reader, table *Table, headers []string) error {
 					continue
 				}
 
-				detectedType := detectDataType(value)
+				column.DataType = mergeDetectedTypes(existingType, detectDataType(value))
+			} else {
+				column.DataType = detectDataType(value)
+			}
+
+			// a datetime column keeps the layouts parsing all its values
+			if datatype := elementType(column.DataType); isDatetimeType(datatype) {
+				candidates, ok := layouts[columnName]
+				if !ok || existingType != column.DataType {
+					candidates = datetimeLayouts[datatype]
+				}
 
-				if (existingType == "integer" && detectedType == "real") ||
-					(existingType == "real" && detectedType == "integer") {
-					column.DataType = "real"
-				} else {
+				layouts[columnName] = filterDatetimeLayouts(value, column.DataType, candidates)
+				if len(layouts[columnName]) == 0 {
 					column.DataType = "text"
 				}
-			} else {
-				column.DataType = detectDataType(value)
 			}
 
 			table.Columns[columnName] = column
-			if column.DataType == "text" {
+			if column.DataType == "text" && column.hasNulls {
 				count--
 			}
 		}
 
-		// stop reading if all columns are texts
+		// stop reading if all columns are texts with null values
 		if count == 0 {
 			break
 		}
@@ -256,12 +357,12 @@ func (column *Column) setTableConstraints(table *Table, columnName string) strin
 	arr := strings.SplitN(columnName, ":", 2)
 
 	if len(arr) == 1 {
-		return sanitize_db_label(arr[0])
+		return table.naming.sanitize(arr[0])
 	}
 
 	constraint := strings.TrimSpace(arr[0])
 	constraint = strings.ToUpper(constraint)
-	columnName = sanitize_db_label(arr[1])
+	columnName = table.naming.sanitize(arr[1])
 
 	if strings.ContainsRune(constraint, 'P') {
 		table.PrimaryKey = columnName
@@ -289,16 +390,24 @@ func (column *Column) setTableConstraints(table *Table, columnName string) strin
 }
 
 // checks all types including no type
+// objects, ragged arrays & arrays of objects are stored as jsonb
 func detectDataType(value string) string {
 	if basicType := detectBasicDataType(value); len(basicType) > 0 {
 		return basicType
 	}
 
-	// try array
-	arr := []any{}
-	err := json.Unmarshal([]byte(value), &arr)
+	var parsed any
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		return "text"
+	}
 
-	if err != nil {
+	if _, isObject := parsed.(map[string]any); isObject {
+		return "jsonb"
+	}
+
+	// try array
+	arr, ok := parsed.([]any)
+	if !ok {
 		return "text"
 	}
 
@@ -306,39 +415,97 @@ func detectDataType(value string) string {
 		return ""
 	}
 
-	firstVal := fmt.Sprintf("%v", arr[0])
+	shape, ok := arrayShape(arr)
+	if !ok || len(shape) > maxArrayDims {
+		return "jsonb"
+	}
+
+	suffix := strings.Repeat("[]", len(shape))
+	elements := flattenArray(arr)
+
+	firstVal := fmt.Sprintf("%v", elements[0])
 	prevType := detectBasicDataType(firstVal)
 	if len(prevType) == 0 || prevType == "text" {
-		return "text[]"
+		return "text" + suffix
 	}
 
-	// Traversing array column
-	for _, subVal := range arr {
+	// Traversing array elements
+	for _, subVal := range elements {
 		subVal := fmt.Sprintf("%v", subVal)
-		detectedType := detectBasicDataType(subVal)
+		prevType = mergeDetectedTypes(prevType, detectBasicDataType(subVal))
 
-		if (prevType == "integer" && detectedType == "real") ||
-			(prevType == "real" && detectedType == "integer") {
-			prevType = "real"
-			continue
+		if prevType == "text" {
+			return "text" + suffix
 		}
+	}
+
+	// JSON numbers don't keep their scale, e.g. 2.50 is read as 2.5, so decimal elements are real
+	if baseType(prevType) == "numeric" {
+		prevType = "real"
+	}
+
+	return prevType + suffix
+}
+
+// returns the type which fits values of both detected types, text if none
+// integers are widened to bigint, decimals of the same scale to numeric(p,s) & other mixed numbers to real
+func mergeDetectedTypes(prevType, detectedType string) string {
+	if prevType == detectedType {
+		return prevType
+	}
+
+	// arrays of different dimensions are kept as JSON documents
+	prevDims, detectedDims := arrayDims(prevType), arrayDims(detectedType)
+	if (prevDims > 0 || prevType == "jsonb") && (detectedDims > 0 || detectedType == "jsonb") && prevDims != detectedDims {
+		return "jsonb"
+	}
+
+	if strings.HasSuffix(prevType, "[]") && strings.HasSuffix(detectedType, "[]") {
+		return mergeDetectedTypes(strings.TrimSuffix(prevType, "[]"), strings.TrimSuffix(detectedType, "[]")) + "[]"
+	}
 
-		if detectedType != prevType {
-			return "text[]"
-		} else {
-			prevType = detectedType
+	if isIntegerType(prevType) && isIntegerType(detectedType) {
+		if integerBitSizes[prevType] > integerBitSizes[detectedType] {
+			return prevType
 		}
+		return detectedType
+	}
+
+	// decimals of the same scale widen to the integer digits of both types
+	prevDigits, prevScale, prevOk := numberDigits(prevType)
+	detectedDigits, detectedScale, detectedOk := numberDigits(detectedType)
+	if prevOk && detectedOk && (prevScale == detectedScale || prevScale == -1 || detectedScale == -1) {
+		scale := max(prevScale, detectedScale)
+		if precision := max(prevDigits, detectedDigits) + scale; precision <= maxNumericPrecision {
+			return fmt.Sprintf("numeric(%d,%d)", precision, scale)
+		}
+	}
+
+	isNumber := func(datatype string) bool {
+		return isIntegerType(datatype) || datatype == "real" || baseType(datatype) == "numeric"
+	}
+	if isNumber(prevType) && isNumber(detectedType) {
+		return "real"
 	}
 
-	return prevType + "[]"
+	return "text"
 }
 
 func detectBasicDataType(value string) string {
-	_, err := strconv.Atoi(value)
+	_, err := strconv.ParseInt(value, 10, integerBitSizes["integer"])
 	if err == nil {
 		return "integer"
 	}
 
+	_, err = strconv.ParseInt(value, 10, integerBitSizes["bigint"])
+	if err == nil {
+		return "bigint"
+	}
+
+	if datatype := detectNumericType(value); datatype != "" {
+		return datatype
+	}
+
 	_, err = strconv.ParseFloat(value, 64)
 	if err == nil {
 		return "real"
@@ -349,22 +516,17 @@ func detectBasicDataType(value string) string {
 		return "boolean"
 	}
 
-	_, err = time.Parse(datetimeFormats["date"], value)
-	if err == nil {
-		return "date"
-	}
-
-	_, err = time.Parse(datetimeFormats["time"], value)
-	if err == nil {
-		return "time"
+	if datatype, _ := detectDatetime(value); datatype != "" {
+		return datatype
 	}
 
-	_, err = time.Parse(datetimeFormats["timestamptz"], value)
-	if err == nil {
-		return "timestamptz"
+	if uuidRegex.MatchString(value) {
+		return "uuid"
 	}
 
-	if !(strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]")) {
+	isArray := strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]")
+	isObject := strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}")
+	if !isArray && !isObject {
 		return "text"
 	}
 
@@ -373,19 +535,13 @@ func detectBasicDataType(value string) string {
 
 func validateAgainstExistingType(value, datatype string) bool {
 	if strings.HasSuffix(datatype, "[]") {
-		arr := []any{}
-		err := json.Unmarshal([]byte(value), &arr)
-
+		arr, err := parseArray(value, datatype)
 		if err != nil {
 			return false
 		}
 
-		if len(arr) == 0 {
-			return true
-		}
-
-		datatype := strings.TrimSuffix(datatype, "[]")
-		for _, value := range arr {
+		datatype := elementType(datatype)
+		for _, value := range flattenArray(arr) {
 			_, ok := validateValueByType(value, datatype)
 			if !ok {
 				return false
@@ -394,6 +550,13 @@ func validateAgainstExistingType(value, datatype string) bool {
 		return true
 	}
 
+	// numeric(p,s) rounds decimals of other scales, which are detected as real instead
+	if _, scale, ok := numericModifiers(detectBasicDataType(value)); ok {
+		if _, existingScale, ok := numericModifiers(datatype); !ok || scale != existingScale {
+			return false
+		}
+	}
+
 	_, ok := validateValueByType(value, datatype)
 	return ok
 }
@@ -401,18 +564,44 @@ func validateAgainstExistingType(value, datatype string) bool {
 func (dbSchema *DB) validateSchema() error {
 	basePath := dbSchema.BasePath
 
+	if err := dbSchema.validateEnumTypes(); err != nil {
+		return err
+	}
+
+	if _, err := time.LoadLocation(dbSchema.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %s", dbSchema.Timezone)
+	}
+
+	if err := dbSchema.Parsing.validate(); err != nil {
+		return fmt.Errorf("invalid parsing options: %v", err)
+	}
+
+	if err := dbSchema.Naming.validate(); err != nil {
+		return fmt.Errorf("invalid naming options: %v", err)
+	}
+
+	if err := dbSchema.setDerivedTables(); err != nil {
+		return err
+	}
+
 	for tableName, table := range dbSchema.Tables {
-		if tableName != sanitize_db_label(tableName) {
-			errorMessage := fmt.Sprintf("table name %s isn't sanitized", tableName)
-			return errors.New(errorMessage)
+		if err := dbSchema.Naming.validateName(tableName, table.Label); err != nil {
+			return fmt.Errorf("table %v", err)
 		}
 
-		filePath := filepath.Join(basePath, table.FileName)
+		table.naming = dbSchema.Naming
+		if err := table.validateLabels(); err != nil {
+			return err
+		}
 
-		if err := checkCSVExist(filePath, tableName); err != nil {
+		if err := table.validateSource(basePath); err != nil {
 			return err
 		}
 
+		if err := table.setLineage(dbSchema.Lineage); err != nil {
+			return fmt.Errorf("invalid column in table %s: %v", tableName, err)
+		}
+
 		primaryKeyFlag := false
 		validCascadeOptions := []string{"CASCADE", "RESTRICT", "SET NULL", "SET DEFAULT", "NO ACTION"}
 
@@ -422,9 +611,8 @@ func (dbSchema *DB) validateSchema() error {
 				return errors.New(errorMessage)
 			}
 
-			if columnName != sanitize_db_label(columnName) {
-				errorMessage := fmt.Sprintf("column %s in table %s isn't sanitized", columnName, tableName)
-				return errors.New(errorMessage)
+			if err := dbSchema.Naming.validateName(columnName, column.Label); err != nil {
+				return fmt.Errorf("column %v in table %s", err, tableName)
 			}
 
 			// Data Type
@@ -433,12 +621,32 @@ func (dbSchema *DB) validateSchema() error {
 				return errors.New(errorMessage)
 			}
 
+			// Parse Options
+			if err := column.setParseOptions(dbSchema.Parsing); err != nil {
+				return fmt.Errorf("invalid parsing options for column %s in table %s: %v", columnName, tableName, err)
+			}
+
+			// Input Format & Timezone
+			if err := column.setInputFormat(dbSchema.Timezone); err != nil {
+				return fmt.Errorf("invalid inputFormat/timezone for column %s in table %s: %v", columnName, tableName, err)
+			}
+
 			// Set Min, Max Constraints
 			if err := column.setMinMaxConstraint(); err != nil {
 				errorMessage := fmt.Sprintf("invalid min/max constraint for column %s in table %s:\n:%v", columnName, tableName, err)
 				return errors.New(errorMessage)
 			}
 
+			// Shared Enum
+			if err := column.setEnumRef(dbSchema.Enums); err != nil {
+				return fmt.Errorf("invalid enumRef for column %s in table %s: %v", columnName, tableName, err)
+			}
+
+			// Pattern & Format
+			if err := column.setPatternConstraint(); err != nil {
+				return fmt.Errorf("invalid pattern/format for column %s in table %s: %v", columnName, tableName, err)
+			}
+
 			// Validate Enum Types
 			if err := column.validateEnums(); err != nil {
 				errorMessage := fmt.Sprintf("invalid enum for column %s in table %s:\n%v", columnName, tableName, err)
@@ -451,6 +659,11 @@ func (dbSchema *DB) validateSchema() error {
 				return errors.New(errorMessage)
 			}
 
+			// Default Expression
+			if err := column.setDefaultExpr(); err != nil {
+				return fmt.Errorf("invalid defaultExpr for column %s in table %s: %v", columnName, tableName, err)
+			}
+
 			// unqiue
 			if column.Unique {
 				if column.Default != nil {
@@ -469,6 +682,10 @@ func (dbSchema *DB) validateSchema() error {
 				return fmt.Errorf(`invalid hashing flag in %s column. only non-unique text, text[] columns can be hashed`, columnName)
 			}
 
+			if err := column.validateHashConfig(); err != nil {
+				return fmt.Errorf(`invalid hash config for %s column in %s table: %v`, columnName, tableName, err)
+			}
+
 			// Primary & Foreign Key
 			if columnName == table.PrimaryKey {
 				primaryKeyFlag = true
@@ -502,6 +719,10 @@ func (dbSchema *DB) validateSchema() error {
 					return errors.New(errorMessage)
 				}
 
+				if (dbSchema.Enums[referredCol.EnumRef].Kind == "type" || column.enumKind == "type") && referredCol.EnumRef != column.EnumRef {
+					return fmt.Errorf("referenced column by %s column in %s table doesn't share its enum type", columnName, tableName)
+				}
+
 				column.OnDelete = strings.ToUpper(column.OnDelete)
 				column.OnUpdate = strings.ToUpper(column.OnUpdate)
 
@@ -519,7 +740,7 @@ func (dbSchema *DB) validateSchema() error {
 					return fmt.Errorf(`invalid onUpdate for %s column in %s table: set_default requires a default value for the column %s in referenced table %s`, columnName, tableName, referredCol.ColumnName, referencedTable.TableName)
 				}
 
-				column.lookup = make(map[string]int)
+				column.lookup = make(map[string][]int)
 			} else if column.OnDelete != "" || column.OnUpdate != "" {
 				return fmt.Errorf(`non foreign-key column %s in %s table can't have onDelete/onUpdate set`, columnName, tableName)
 			}
@@ -533,6 +754,25 @@ func (dbSchema *DB) validateSchema() error {
 			return errors.New(errorMessage)
 		}
 
+		// Primary Key Strategy
+		if err := table.setPrimaryKeyStrategy(); err != nil {
+			return fmt.Errorf("invalid primaryKeyStrategy in table %s: %v", tableName, err)
+		}
+
+		if err := table.validateDedup(); err != nil {
+			return fmt.Errorf("invalid dedup in table %s: %v", tableName, err)
+		}
+
+		// Table Checks
+		if err := table.setChecks(); err != nil {
+			return fmt.Errorf("invalid checks in table %s: %v", tableName, err)
+		}
+
+		// Transforms & Computed Columns
+		if err := table.setTransforms(); err != nil {
+			return fmt.Errorf("invalid transforms in table %s: %v", tableName, err)
+		}
+
 		dbSchema.Tables[tableName] = table
 	}
 
@@ -568,6 +808,10 @@ func (appConfig *AppCongif) setTables(dbSchema *DB) {
 			}
 		}
 
+		for _, column := range table.LineageColumns() {
+			columns = append(columns, column.ColumnName)
+		}
+
 		if len(foreignColumns) == 0 {
 			foreignColumns = nil
 		}
@@ -1,6 +1,10 @@
 package main
 
-import "testing"
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
 
 func Test_detectDataType(t *testing.T) {
 	type args struct {
@@ -71,6 +75,71 @@ func Test_detectDataType(t *testing.T) {
 			args: args{value: "+4"},
 			want: "integer",
 		},
+		{
+			name: "bigint",
+			args: args{value: "5000000000"},
+			want: "bigint",
+		},
+		{
+			name: "decimal",
+			args: args{value: "-012.50"},
+			want: "numeric(4,2)",
+		},
+		{
+			name: "fraction",
+			args: args{value: ".5"},
+			want: "numeric(1,1)",
+		},
+		{
+			name: "exponent",
+			args: args{value: "1.5e3"},
+			want: "real",
+		},
+		{
+			name: "uuid",
+			args: args{value: "7C9E6679-7425-40DE-944B-E07FC1F90AE7"},
+			want: "uuid",
+		},
+		{
+			name: "uuid arr",
+			args: args{value: "[\"7c9e6679-7425-40de-944b-e07fc1f90ae7\"]"},
+			want: "uuid[]",
+		},
+		{
+			name: "2d integer arr",
+			args: args{value: "[[1, 2], [3, 4]]"},
+			want: "integer[][]",
+		},
+		{
+			name: "3d real arr",
+			args: args{value: "[[[1.5, 2]], [[3, 4]]]"},
+			want: "real[][][]",
+		},
+		{
+			name: "2d text arr",
+			args: args{value: "[[\"a\", 1], [\"b\", 2]]"},
+			want: "text[][]",
+		},
+		{
+			name: "object",
+			args: args{value: "{\"lat\": 12.5, \"lng\": 77.1}"},
+			want: "jsonb",
+		},
+		{
+			name: "ragged arr",
+			args: args{value: "[[1, 2], [3]]"},
+			want: "jsonb",
+		},
+		{
+			name: "arr of objects",
+			args: args{value: "[{\"a\": 1}, {\"a\": 2}]"},
+			want: "jsonb",
+		},
+		{
+			name: "invalid object",
+			args: args{value: "{data}"},
+			want: "text",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -80,3 +149,34 @@ func Test_detectDataType(t *testing.T) {
 		})
 	}
 }
+
+func Test_setColumnTypes_numeric(t *testing.T) {
+	data := `price,qty,ratio,amount
+12.50,1,0.5,1.5
+3.00,2.5,0.25,5000000000
+1200.75,3,,
+`
+	reader := &csvReader{Reader: csv.NewReader(strings.NewReader(data))}
+	headers, _ := reader.Read()
+
+	table := &Table{Columns: map[string]Column{}}
+	for _, header := range headers {
+		table.Columns[header] = Column{ColumnName: header}
+	}
+
+	if err := setColumnTypes(reader, table, headers, &ParseOptions{}); err != nil {
+		t.Fatalf("setColumnTypes() error = %v", err)
+	}
+
+	want := map[string]string{
+		"price":  "numeric(6,2)",
+		"qty":    "numeric(11,1)",
+		"ratio":  "real",
+		"amount": "numeric(20,1)",
+	}
+	for columnName, wantType := range want {
+		if got := table.Columns[columnName].DataType; got != wantType {
+			t.Errorf("%s column = %v, want %v", columnName, got, wantType)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// tables sharded across files, e.g. orders_2024_01.csv & orders_2024_02.csv set as files: ["orders_*.csv"]
+// files are read one after another as a single source, their headers should have the same columns
+
+type shardStart struct {
+	fileName  string // source name of the file
+	row       int    // row no. of the first data row of the file among all the rows of table
+	fileRow   int    // row no. of the first data row in the file, 1 for files without headers
+	sheetRows []int  // row no. in the sheet of each data row, set for workbooks which skip empty rows
+}
+
+// returns the files of table, globs of Files are expanded in sorted order
+func (table *Table) sourceFiles(basePath string) ([]string, error) {
+	if table.FileName == "" && len(table.Files) == 0 {
+		return nil, fmt.Errorf("no source is set for table %s, set fileName or files", table.TableName)
+	}
+
+	if len(table.Files) == 0 {
+		return []string{table.FileName}, nil
+	}
+
+	dir := os.DirFS(basePath)
+	files := []string{}
+
+	for _, pattern := range table.Files {
+		matches, err := fs.Glob(dir, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file pattern %s for table %s", pattern, table.TableName)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s for table %s", pattern, table.TableName)
+		}
+
+		for _, match := range matches {
+			if !slices.Contains(files, match) {
+				files = append(files, match)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// returns the location of a row used in messages, e.g. row no. 4 of orders_2.csv
+// rowIdx counts the rows of all the files of table with the headers as row no. 1
+func (table *Table) rowLocation(rowIdx int) string {
+	fileName, row := table.rowSource(rowIdx)
+	if fileName == "" {
+		return fmt.Sprintf("row no. %d", row)
+	}
+
+	return fmt.Sprintf("row no. %d of %s", row, fileName)
+}
+
+// returns the file of a row & its row no. in the file, file is empty if unknown
+func (table *Table) rowSource(rowIdx int) (string, int) {
+	for idx := len(table.shards) - 1; idx >= 0; idx-- {
+		shard := table.shards[idx]
+		if rowIdx >= shard.row {
+			if offset := rowIdx - shard.row; offset < len(shard.sheetRows) {
+				return shard.fileName, shard.sheetRows[offset]
+			}
+			return shard.fileName, rowIdx - shard.row + shard.fileRow
+		}
+	}
+
+	return "", rowIdx
+}
+
+// groups the files matching the files of existing tables, e.g. orders_*.csv, into those tables
+// sharding is set only through files of schema.json, other files are separate tables
+func groupShards(tables []Table, existingTables map[string]Table) []Table {
+	grouped := make([]Table, 0, len(tables))
+	added := map[string]bool{}
+
+	for _, table := range tables {
+		tableName := shardTable(table, existingTables)
+		if tableName == "" {
+			grouped = append(grouped, table)
+			continue
+		}
+
+		if added[tableName] {
+			continue
+		}
+		added[tableName] = true
+
+		existing := existingTables[tableName]
+		grouped = append(grouped, Table{TableName: tableName, Label: existing.Label, Files: existing.Files, Format: table.Format})
+	}
+
+	return grouped
+}
+
+// returns the existing table whose files match the file of table, empty if none
+func shardTable(table Table, existingTables map[string]Table) string {
+	if table.FileName == "" || table.Sheet != "" || table.Entry != "" {
+		return ""
+	}
+
+	for _, tableName := range slices.Sorted(maps.Keys(existingTables)) {
+		for _, pattern := range existingTables[tableName].Files {
+			if matched, _ := path.Match(pattern, table.FileName); matched {
+				return tableName
+			}
+		}
+	}
+
+	return ""
+}
+
+// returns the index in headers of each column of the first headers, columns are matched by name
+func (table *Table) matchShardHeaders(first, headers []string) ([]int, error) {
+	if len(first) != len(headers) {
+		return nil, fmt.Errorf("expected %d columns, found %d", len(first), len(headers))
+	}
+
+	indexes := make(map[string]int, len(headers))
+	for idx, header := range headers {
+		indexes[table.headerColumnName(header)] = idx
+	}
+
+	order := make([]int, len(first))
+	for idx, header := range first {
+		columnName := table.headerColumnName(header)
+		position, ok := indexes[columnName]
+		if !ok {
+			return nil, fmt.Errorf("column %s not found", columnName)
+		}
+		order[idx] = position
+	}
+
+	return order, nil
+}
+
+// returns the column name of a header without its constraints, e.g. PN:id -> id
+func (table *Table) headerColumnName(header string) string {
+	return table.naming.sanitize(headerLabel(header))
+}
+
+// returns the column of a header, matched by name or else by the label of renamed columns
+func (table *Table) headerColumn(header string) (string, bool) {
+	columnName := table.headerColumnName(header)
+	if _, ok := table.Columns[columnName]; ok {
+		return columnName, true
+	}
+
+	label := headerLabel(header)
+	for columnName, column := range table.Columns {
+		if column.Label == label {
+			return columnName, true
+		}
+	}
+
+	return "", false
+}
+
+// returns the original text of a header without its constraints, e.g. U:Student Father -> Student Father
+func headerLabel(header string) string {
+	arr := strings.SplitN(strings.TrimSpace(header), ":", 2)
+	return strings.TrimSpace(arr[len(arr)-1])
+}
+
+// reads the files of table one after another, rows of later files are ordered as per the headers of the first
+// files without a header row are read by position, the table headers are returned as the first row
+type shardReader struct {
+	basePath string
+	table    *Table
+	files    []string
+	fileIdx  int
+	current  sourceReader
+	headers  []string // headers of the first file
+	order    []int    // index in the current file of each header, nil for the first file
+	row      int      // row no. in the current file
+	rows     int      // data rows read so far
+}
+
+// opens the source files of table in basePath, the first file is opened right away
+func openSource(basePath string, table *Table) (*shardReader, error) {
+	files, err := table.sourceFiles(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	table.shards = nil
+	reader := &shardReader{basePath: basePath, table: table, files: files, fileIdx: -1}
+
+	if err := reader.openNext(); err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// closes the current file & opens the next one
+func (reader *shardReader) openNext() error {
+	if reader.current != nil {
+		if err := reader.current.Close(); err != nil {
+			return err
+		}
+		reader.current = nil
+	}
+
+	reader.fileIdx++
+	reader.row = 0
+
+	current, err := openSourceFile(reader.basePath, reader.table, reader.files[reader.fileIdx])
+	if err != nil {
+		return err
+	}
+
+	reader.current = current
+	return nil
+}
+
+func (reader *shardReader) fileName() string {
+	return reader.table.shardName(reader.files[reader.fileIdx])
+}
+
+func (reader *shardReader) Read() ([]string, error) {
+	headerless := len(reader.table.Headers) > 0
+	if headerless && reader.headers == nil {
+		reader.headers = slices.Clone(reader.table.Headers)
+		return slices.Clone(reader.headers), nil
+	}
+
+	for {
+		row, err := reader.current.Read()
+
+		if err == io.EOF && reader.row == 0 && reader.headers != nil && !headerless {
+			return nil, fmt.Errorf("no headers found in %s", reader.fileName())
+		}
+
+		if err == io.EOF && reader.headers != nil && reader.fileIdx < len(reader.files)-1 {
+			if err := reader.openNext(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err == io.EOF {
+			return nil, err
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", reader.fileName(), err)
+		}
+
+		reader.row++
+
+		// headers
+		if reader.row == 1 && !headerless {
+			if reader.headers == nil {
+				reader.headers = slices.Clone(row)
+				return row, nil
+			}
+
+			order, err := reader.table.matchShardHeaders(reader.headers, row)
+			if err != nil {
+				return nil, fmt.Errorf("headers of %s don't match %s: %v", reader.fileName(), reader.table.shardName(reader.files[0]), err)
+			}
+			reader.order = order
+			continue
+		}
+
+		if len(row) != len(reader.headers) {
+			return nil, fmt.Errorf("%s: row no. %d has %d fields, expected %d", reader.fileName(), reader.row, len(row), len(reader.headers))
+		}
+
+		if reader.row == 1 || (reader.row == 2 && !headerless) {
+			shard := shardStart{fileName: reader.fileName(), row: reader.rows + 2, fileRow: reader.row}
+			if sheet, ok := reader.current.(*rowsReader); ok {
+				shard.sheetRows = sheet.numbers[sheet.next-1:]
+			}
+			reader.table.shards = append(reader.table.shards, shard)
+		}
+		reader.rows++
+
+		if reader.order == nil {
+			return row, nil
+		}
+
+		ordered := make([]string, len(row))
+		for idx, position := range reader.order {
+			ordered[idx] = row[position]
+		}
+		return ordered, nil
+	}
+}
+
+func (reader *shardReader) Close() error {
+	if reader.current == nil {
+		return nil
+	}
+	return reader.current.Close()
+}
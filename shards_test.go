@@ -0,0 +1,146 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_groupShards(t *testing.T) {
+	tables := []Table{
+		{TableName: "students_2024_01", FileName: "students_2024_01.csv", Format: "csv"},
+		{TableName: "courses", FileName: "courses.csv", Format: "csv"},
+		{TableName: "students_2024_02", FileName: "students_2024_02.csv", Format: "csv"},
+		{TableName: "level_1", FileName: "level_1.csv", Format: "csv"},
+		{TableName: "level_2", FileName: "level_2.csv", Format: "csv"},
+		{TableName: "sales_1", FileName: "sales.xlsx", Format: "xlsx", Sheet: "sales_1"},
+	}
+	existingTables := map[string]Table{
+		"students": {TableName: "students", Label: "Students", Files: []string{"students_*.csv"}},
+		"courses":  {TableName: "courses", FileName: "courses.csv"},
+	}
+
+	// files are sharded only through files of existing tables
+	want := []Table{
+		{TableName: "students", Label: "Students", Files: []string{"students_*.csv"}, Format: "csv"},
+		{TableName: "courses", FileName: "courses.csv", Format: "csv"},
+		{TableName: "level_1", FileName: "level_1.csv", Format: "csv"},
+		{TableName: "level_2", FileName: "level_2.csv", Format: "csv"},
+		{TableName: "sales_1", FileName: "sales.xlsx", Format: "xlsx", Sheet: "sales_1"},
+	}
+
+	if got := groupShards(tables, existingTables); !reflect.DeepEqual(got, want) {
+		t.Errorf("groupShards() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_matchShardHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    []int
+		wantErr bool
+	}{
+		{name: "same order", headers: []string{"PN:id", "name", "dob"}, want: []int{0, 1, 2}},
+		{name: "reordered", headers: []string{"name ", "dob", "U:id"}, want: []int{2, 0, 1}},
+		{name: "missing column", headers: []string{"id", "name", "age"}, wantErr: true},
+		{name: "extra column", headers: []string{"id", "name", "dob", "age"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &Table{}
+			got, err := table.matchShardHeaders([]string{"PN:id", "name", "dob"}, tt.headers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchShardHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchShardHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_shardReader(t *testing.T) {
+	basePath := t.TempDir()
+	files := map[string]string{
+		"orders_1.csv": "id,qty\n1,5\n2,6\n",
+		"orders_2.csv": "qty,id\n7,3\n",
+		"orders_3.csv": "id,qty\n",
+		"orders_4.csv": "id,qty\n4,8\n",
+	}
+	for fileName, data := range files {
+		if err := os.WriteFile(filepath.Join(basePath, fileName), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	table := Table{TableName: "orders", Files: []string{"orders_*.csv"}, Format: "csv"}
+	reader, err := openSource(basePath, &table)
+	if err != nil {
+		t.Fatalf("openSource() error = %v", err)
+	}
+	defer reader.Close()
+
+	want := [][]string{{"id", "qty"}, {"1", "5"}, {"2", "6"}, {"3", "7"}, {"4", "8"}}
+	for _, wantRow := range want {
+		row, err := reader.Read()
+		if err != nil {
+			t.Fatalf("shardReader.Read() error = %v", err)
+		}
+		if !reflect.DeepEqual(row, wantRow) {
+			t.Errorf("shardReader.Read() = %q, want %q", row, wantRow)
+		}
+	}
+
+	if _, err := reader.Read(); err != io.EOF {
+		t.Errorf("shardReader.Read() error = %v, want EOF", err)
+	}
+
+	locations := map[int]string{2: "row no. 2 of orders_1.csv", 4: "row no. 2 of orders_2.csv", 5: "row no. 2 of orders_4.csv"}
+	for rowIdx, want := range locations {
+		if got := table.rowLocation(rowIdx); got != want {
+			t.Errorf("Table.rowLocation(%d) = %v, want %v", rowIdx, got, want)
+		}
+	}
+}
+
+func Test_shardReader_headerless(t *testing.T) {
+	basePath := t.TempDir()
+	files := map[string]string{
+		"events_1.csv": "1,login\n2,logout\n",
+		"events_2.csv": "3,login,extra\n",
+	}
+	for fileName, data := range files {
+		if err := os.WriteFile(filepath.Join(basePath, fileName), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	table := Table{TableName: "events", Files: []string{"events_*.csv"}, Format: "csv", Headers: []string{"id", "action"}}
+	reader, err := openSource(basePath, &table)
+	if err != nil {
+		t.Fatalf("openSource() error = %v", err)
+	}
+	defer reader.Close()
+
+	want := [][]string{{"id", "action"}, {"1", "login"}, {"2", "logout"}}
+	for _, wantRow := range want {
+		row, err := reader.Read()
+		if err != nil {
+			t.Fatalf("shardReader.Read() error = %v", err)
+		}
+		if !reflect.DeepEqual(row, wantRow) {
+			t.Errorf("shardReader.Read() = %q, want %q", row, wantRow)
+		}
+	}
+
+	if got, want := table.rowLocation(3), "row no. 2 of events_1.csv"; got != want {
+		t.Errorf("Table.rowLocation() = %v, want %v", got, want)
+	}
+
+	if _, err := reader.Read(); err == nil {
+		t.Errorf("shardReader.Read() should reject rows with extra fields")
+	}
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// file extensions of the formats of table sources, compressed by gzip (.gz) or zip archives
+var sourceFormats = map[string][]string{
+	"csv":   {".csv"},
+	"tsv":   {".tsv", ".tab"},
+	"jsonl": {".jsonl", ".ndjson"},
+	"xlsx":  {".xlsx"},
+}
+
+// reads the rows of a table source, the first row holds the headers
+type sourceReader interface {
+	Read() ([]string, error) // io.EOF after the last row
+	Close() error
+}
+
+// returns the format & compression (gz or zip) of a file name, empty format if unsupported
+// formats of zip archives are detected per entry
+func detectSourceFormat(fileName string) (string, string) {
+	name := strings.ToLower(fileName)
+	compression := ""
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "", "zip"
+	case strings.HasSuffix(name, ".gz"):
+		compression = "gz"
+		name = strings.TrimSuffix(name, ".gz")
+	}
+
+	ext := filepath.Ext(name)
+	for format, extensions := range sourceFormats {
+		if slices.Contains(extensions, ext) {
+			// workbooks are already compressed
+			if format == "xlsx" && compression != "" {
+				return "", compression
+			}
+			return format, compression
+		}
+	}
+
+	return "", compression
+}
+
+// returns the file name without format & compression extensions, e.g. orders.csv.gz -> orders
+func trimSourceExt(fileName string) string {
+	name := filepath.Base(fileName)
+	if strings.HasSuffix(strings.ToLower(name), ".gz") {
+		name = name[:len(name)-len(".gz")]
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// lists the tables of supported files in dataPath, one per workbook sheet & zip archive entry
+// files matching the files of existing tables are listed as those tables
+func listSources(dataPath string, naming NamingOptions, existingTables map[string]Table) ([]Table, error) {
+	dirList, err := os.ReadDir(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := []Table{}
+
+	for _, file := range dirList {
+		if file.IsDir() {
+			continue
+		}
+
+		fileName := file.Name()
+		format, compression := detectSourceFormat(fileName)
+
+		switch {
+		case compression == "zip":
+			archive, err := zip.OpenReader(filepath.Join(dataPath, fileName))
+			if err != nil {
+				return nil, fmt.Errorf("failed to open %s: %v", fileName, err)
+			}
+
+			for _, entry := range archive.File {
+				format, compression := detectSourceFormat(entry.Name)
+				if entry.FileInfo().IsDir() || format == "" || format == "xlsx" || compression != "" {
+					continue
+				}
+
+				tableName := naming.sanitize(trimSourceExt(entry.Name))
+				tables = append(tables, Table{TableName: tableName, Label: trimSourceExt(entry.Name), FileName: fileName, Format: format, Entry: entry.Name})
+			}
+
+			archive.Close()
+
+		case format == "xlsx":
+			archive, err := zip.OpenReader(filepath.Join(dataPath, fileName))
+			if err != nil {
+				return nil, fmt.Errorf("failed to open %s: %v", fileName, err)
+			}
+
+			sheets, err := readXLSXSheets(&archive.Reader)
+			archive.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read sheets of %s: %v", fileName, err)
+			}
+
+			for _, sheet := range sheets {
+				tables = append(tables, Table{TableName: naming.sanitize(sheet.Name), Label: sheet.Name, FileName: fileName, Format: format, Sheet: sheet.Name})
+			}
+
+		case format != "":
+			tableName := naming.sanitize(trimSourceExt(fileName))
+			tables = append(tables, Table{TableName: tableName, Label: trimSourceExt(fileName), FileName: fileName, Format: format})
+		}
+	}
+
+	return groupShards(tables, existingTables), nil
+}
+
+// name of the table source used in messages, e.g. sales.xlsx:2024
+func (table *Table) sourceName() string {
+	if len(table.Files) > 0 {
+		return strings.Join(table.Files, ", ")
+	}
+	return table.shardName(table.FileName)
+}
+
+// name of a file of the table used in messages
+func (table *Table) shardName(fileName string) string {
+	switch {
+	case table.Sheet != "":
+		return fileName + ":" + table.Sheet
+	case table.Entry != "":
+		return fileName + ":" + table.Entry
+	}
+	return fileName
+}
+
+// validates the source files of table & sets the format of schemas written before formats were recorded
+func (table *Table) validateSource(basePath string) error {
+	if table.FileName != "" && len(table.Files) > 0 {
+		return fmt.Errorf("either fileName or files should be set for table %s", table.TableName)
+	}
+
+	// tables of an introspected database have no source, commands reading sources reject them by requireSources
+	if table.FileName == "" && len(table.Files) == 0 {
+		return nil
+	}
+
+	files, err := table.sourceFiles(basePath)
+	if err != nil {
+		return err
+	}
+
+	for _, fileName := range files {
+		if err := table.validateSourceFile(basePath, fileName); err != nil {
+			return err
+		}
+	}
+
+	if (len(table.Headers) > 0 || table.Headerless) && table.Format == "jsonl" {
+		return fmt.Errorf("headers can't be set for jsonl source of table %s", table.TableName)
+	}
+
+	if table.Headerless && len(table.Headers) == 0 {
+		return fmt.Errorf("headers should be set for headerless table %s, run schema --merge to name them positionally", table.TableName)
+	}
+
+	seen := make(map[string]bool, len(table.Headers))
+	for _, header := range table.Headers {
+		_, isColumn := table.Columns[header]
+		_, isExtracted := table.extracted[header]
+		if !isColumn && !isExtracted {
+			return fmt.Errorf("header %s of table %s isn't a column", header, table.TableName)
+		}
+
+		if seen[header] {
+			return fmt.Errorf("duplicate header %s in table %s", header, table.TableName)
+		}
+		seen[header] = true
+	}
+
+	return nil
+}
+
+// errors if a table has no source, only app works with the sourceless tables of an introspected database
+func (dbSchema *DB) requireSources(command string) error {
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		table := dbSchema.Tables[tableName]
+		if table.FileName == "" && len(table.Files) == 0 && table.DerivedFrom == "" {
+			return fmt.Errorf("table %s has no source, %s requires fileName or files for every table", tableName, command)
+		}
+	}
+
+	return nil
+}
+
+// names the columns positionally (col_1, col_2, ...) by the fields of the first row of source
+func (table *Table) setPositionalHeaders(basePath string) error {
+	if table.Format == "jsonl" {
+		return fmt.Errorf("jsonl source of table %s can't be headerless", table.TableName)
+	}
+
+	files, err := table.sourceFiles(basePath)
+	if err != nil {
+		return err
+	}
+
+	reader, err := openSourceFile(basePath, table, files[0])
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	row, err := reader.Read()
+	if err == io.EOF {
+		return fmt.Errorf("no rows found in %s", table.shardName(files[0]))
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %v", table.shardName(files[0]), err)
+	}
+
+	table.Headers = make([]string, len(row))
+	for idx := range row {
+		table.Headers[idx] = fmt.Sprintf("col_%d", idx+1)
+	}
+
+	return nil
+}
+
+func (table *Table) validateSourceFile(basePath, fileName string) error {
+	if err := checkCSVExist(filepath.Join(basePath, fileName), table.TableName); err != nil {
+		return err
+	}
+
+	format, compression := detectSourceFormat(fileName)
+	if compression == "zip" {
+		if table.Entry == "" {
+			return fmt.Errorf("entry of zip archive %s isn't set for table %s", fileName, table.TableName)
+		}
+		format, compression = detectSourceFormat(table.Entry)
+		if format == "xlsx" || compression != "" {
+			format = ""
+		}
+	} else if table.Entry != "" {
+		return fmt.Errorf("entry is allowed only for zip archives, found in table %s", table.TableName)
+	}
+
+	if format == "" {
+		return fmt.Errorf("unsupported source %s of table %s", table.shardName(fileName), table.TableName)
+	}
+
+	if table.Format == "" {
+		table.Format = format
+	}
+
+	if table.Format != format {
+		return fmt.Errorf("format %s of table %s doesn't match its source %s", table.Format, table.TableName, table.shardName(fileName))
+	}
+
+	if (format == "xlsx") != (table.Sheet != "") {
+		return fmt.Errorf("sheet should be set for xlsx sources only, table %s", table.TableName)
+	}
+
+	return nil
+}
+
+// opens a source file of table in basePath
+func openSourceFile(basePath string, table *Table, fileName string) (sourceReader, error) {
+	filePath := filepath.Join(basePath, fileName)
+	_, compression := detectSourceFormat(fileName)
+
+	if table.Format == "xlsx" {
+		archive, err := zip.OpenReader(filePath)
+		if err != nil {
+			return nil, err
+		}
+		defer archive.Close()
+
+		rows, numbers, err := readXLSXRows(&archive.Reader, table.Sheet)
+		if err != nil {
+			return nil, err
+		}
+		return &rowsReader{rows: rows, numbers: numbers}, nil
+	}
+
+	var fp io.Reader
+	var closers multiCloser
+
+	switch compression {
+	case "zip":
+		archive, err := zip.OpenReader(filePath)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, archive)
+
+		entry, err := archive.Open(table.Entry)
+		if err != nil {
+			closers.Close()
+			return nil, fmt.Errorf("failed to open %s: %v", table.shardName(fileName), err)
+		}
+		closers = append(closers, entry)
+		fp = entry
+
+	default:
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, file)
+		fp = file
+
+		if compression == "gz" {
+			gzipReader, err := gzip.NewReader(file)
+			if err != nil {
+				closers.Close()
+				return nil, fmt.Errorf("failed to decompress %s: %v", fileName, err)
+			}
+			closers = append(closers, gzipReader)
+			fp = gzipReader
+		}
+	}
+
+	switch table.Format {
+	case "csv", "tsv":
+		reader := csv.NewReader(fp)
+		if table.Format == "tsv" {
+			reader.Comma = '\t'
+		}
+		return &csvReader{Reader: reader, multiCloser: closers}, nil
+	case "jsonl":
+		return &jsonlReader{reader: bufio.NewReader(fp), multiCloser: closers}, nil
+	}
+
+	closers.Close()
+	return nil, fmt.Errorf("unsupported format %s of table %s", table.Format, table.TableName)
+}
+
+// closes the readers in reverse order
+type multiCloser []io.Closer
+
+func (closers multiCloser) Close() error {
+	errs := []error{}
+	for idx := len(closers) - 1; idx >= 0; idx-- {
+		if err := closers[idx].Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type csvReader struct {
+	*csv.Reader
+	multiCloser
+}
+
+// rows read in advance, used for workbooks
+type rowsReader struct {
+	rows    [][]string
+	numbers []int // row no. of each row in the sheet
+	next    int   // index of the next row
+}
+
+func (reader *rowsReader) Read() ([]string, error) {
+	if reader.next == len(reader.rows) {
+		return nil, io.EOF
+	}
+
+	reader.next++
+	return reader.rows[reader.next-1], nil
+}
+
+func (reader *rowsReader) Close() error {
+	return nil
+}
+
+// reads JSON Lines, keys of the first object are the headers
+// strings are read as is, null as empty, arrays & objects as compact JSON
+type jsonlReader struct {
+	reader  *bufio.Reader
+	headers []string
+	pending []string // first row, read along with the headers
+	line    int
+	multiCloser
+}
+
+func (reader *jsonlReader) Read() ([]string, error) {
+	if reader.pending != nil {
+		row := reader.pending
+		reader.pending = nil
+		return row, nil
+	}
+
+	line, err := reader.nextLine()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, values, err := parseJSONLine(line)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", reader.line, err)
+	}
+
+	if reader.headers == nil {
+		reader.headers = keys
+		reader.pending = make([]string, len(keys))
+		for idx, key := range keys {
+			reader.pending[idx] = values[key]
+		}
+		return slices.Clone(keys), nil
+	}
+
+	for _, key := range keys {
+		if !slices.Contains(reader.headers, key) {
+			return nil, fmt.Errorf("line %d: key %s isn't present in the first line", reader.line, key)
+		}
+	}
+
+	row := make([]string, len(reader.headers))
+	for idx, key := range reader.headers {
+		row[idx] = values[key]
+	}
+
+	return row, nil
+}
+
+// returns the next non-empty line
+func (reader *jsonlReader) nextLine() ([]byte, error) {
+	for {
+		line, err := reader.reader.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return nil, err
+		}
+		reader.line++
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+// returns the keys of a JSON object in order along with their cell values
+func parseJSONLine(line []byte) ([]string, map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, nil, errors.New("should be a JSON object")
+	}
+
+	keys := []string{}
+	values := map[string]string{}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := token.(string)
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := values[key]; ok {
+			return nil, nil, fmt.Errorf("duplicate key %s", key)
+		}
+
+		keys = append(keys, key)
+		values[key] = jsonCellValue(raw)
+	}
+
+	return keys, values, nil
+}
+
+func jsonCellValue(raw json.RawMessage) string {
+	switch raw[0] {
+	case '"':
+		var str string
+		json.Unmarshal(raw, &str)
+		return str
+	case 'n':
+		return ""
+	}
+
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, raw); err != nil {
+		return string(raw)
+	}
+	return buffer.String()
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_detectSourceFormat(t *testing.T) {
+	tests := []struct {
+		fileName        string
+		wantFormat      string
+		wantCompression string
+	}{
+		{fileName: "orders.csv", wantFormat: "csv"},
+		{fileName: "orders.TSV", wantFormat: "tsv"},
+		{fileName: "events.ndjson", wantFormat: "jsonl"},
+		{fileName: "sales.xlsx", wantFormat: "xlsx"},
+		{fileName: "orders.csv.gz", wantFormat: "csv", wantCompression: "gz"},
+		{fileName: "exports.zip", wantCompression: "zip"},
+		{fileName: "sales.xlsx.gz", wantCompression: "gz"},
+		{fileName: "schema.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			format, compression := detectSourceFormat(tt.fileName)
+			if format != tt.wantFormat || compression != tt.wantCompression {
+				t.Errorf("detectSourceFormat() = %v, %v, want %v, %v", format, compression, tt.wantFormat, tt.wantCompression)
+			}
+		})
+	}
+}
+
+func Test_jsonlReader(t *testing.T) {
+	data := `{"id": 1, "name": "Ram", "tags": ["a", "b"], "meta": {"x": 1.50}}
+
+{"name": null, "id": 2, "tags": []}
+`
+	reader := &jsonlReader{reader: newBufioReader(data)}
+
+	want := [][]string{
+		{"id", "name", "tags", "meta"},
+		{"1", "Ram", `["a","b"]`, `{"x":1.50}`},
+		{"2", "", "[]", ""},
+	}
+
+	for _, wantRow := range want {
+		row, err := reader.Read()
+		if err != nil {
+			t.Fatalf("jsonlReader.Read() error = %v", err)
+		}
+		if !reflect.DeepEqual(row, wantRow) {
+			t.Errorf("jsonlReader.Read() = %q, want %q", row, wantRow)
+		}
+	}
+
+	if _, err := reader.Read(); err != io.EOF {
+		t.Errorf("jsonlReader.Read() error = %v, want EOF", err)
+	}
+
+	reader = &jsonlReader{reader: newBufioReader("{\"id\": 1}\n{\"id\": 2, \"extra\": 3}\n")}
+	reader.Read()
+	reader.Read()
+	if _, err := reader.Read(); err == nil {
+		t.Errorf("jsonlReader.Read() should reject unknown keys")
+	}
+}
+
+func Test_xlsxFormatType(t *testing.T) {
+	tests := map[string]string{
+		"dd/mm/yyyy":          "date",
+		"yyyy-mm-dd hh:mm:ss": "timestamptz",
+		"h:mm AM/PM":          "time",
+		"#,##0.00":            "",
+		`[Red]0.00;"days"0`:   "",
+		`[$-409]mmmm d, yyyy`: "date",
+	}
+	for code, want := range tests {
+		if got := xlsxFormatType(code); got != want {
+			t.Errorf("xlsxFormatType(%q) = %v, want %v", code, got, want)
+		}
+	}
+
+	if got := xlsxDatetime("45474.5", "timestamptz"); got != "2024-07-01 12:00:00" {
+		t.Errorf("xlsxDatetime() = %v, want 2024-07-01 12:00:00", got)
+	}
+}
+
+func Test_readXLSXRows(t *testing.T) {
+	newWorkbook := func(sheetData string) []byte {
+		parts := map[string]string{
+			"xl/workbook.xml":            `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="marks" r:id="rId1"/></sheets></workbook>`,
+			"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+			"xl/worksheets/sheet1.xml":   `<worksheet><sheetData>` + sheetData + `</sheetData></worksheet>`,
+		}
+
+		var buffer bytes.Buffer
+		writer := zip.NewWriter(&buffer)
+		for name, content := range parts {
+			file, _ := writer.Create(name)
+			file.Write([]byte(content))
+		}
+		writer.Close()
+
+		return buffer.Bytes()
+	}
+	readRows := func(sheetData string) ([][]string, []int, error) {
+		data := newWorkbook(sheetData)
+		archive, _ := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		return readXLSXRows(archive, "marks")
+	}
+
+	header := `<row r="1"><c r="A1" t="inlineStr"><is><t>roll</t></is></c><c r="B1" t="inlineStr"><is><t>marks</t></is></c><c r="C1" s="1"/></row>`
+
+	rows, numbers, err := readRows(header + `<row r="2"><c r="A2"><v>1</v></c><c r="D2" s="1"/></row><row r="5"><c r="B5"><v>90</v></c></row>`)
+	if err != nil || !reflect.DeepEqual(rows, [][]string{{"roll", "marks"}, {"1", ""}, {"", "90"}}) || !reflect.DeepEqual(numbers, []int{1, 2, 5}) {
+		t.Errorf("readXLSXRows() = %q, %v, %v, want padded rows without empty cells & their sheet row numbers", rows, numbers, err)
+	}
+
+	_, _, err = readRows(header + `<row r="3"><c r="A3"><v>1</v></c><c r="C3"><v>90</v></c></row>`)
+	if err == nil || !strings.Contains(err.Error(), "sheet marks row 3") {
+		t.Errorf("readXLSXRows() error = %v, want wrong number of fields in sheet marks row 3", err)
+	}
+
+	basePath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(basePath, "marks.xlsx"), newWorkbook(header+`<row r="3"><c r="A3"><v>1</v></c></row><row r="7"><c r="A7"><v>2</v></c></row>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	table := Table{TableName: "marks", FileName: "marks.xlsx", Sheet: "marks", Format: "xlsx"}
+	reader, err := openSource(basePath, &table)
+	if err != nil {
+		t.Fatalf("openSource() error = %v", err)
+	}
+	defer reader.Close()
+	for {
+		if _, err := reader.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("shardReader.Read() error = %v", err)
+		}
+	}
+
+	if got, want := table.rowLocation(3), "row no. 7 of marks.xlsx:marks"; got != want {
+		t.Errorf("Table.rowLocation() = %v, want %v", got, want)
+	}
+}
+
+func newBufioReader(data string) *bufio.Reader {
+	return bufio.NewReader(strings.NewReader(data))
+}
+
+func TestDB_requireSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		tables  map[string]Table
+		wantErr bool
+	}{
+		{name: "file", tables: map[string]Table{"orders": {FileName: "orders.csv"}}},
+		{name: "shards", tables: map[string]Table{"orders": {Files: []string{"orders_*.csv"}}}},
+		{name: "derived", tables: map[string]Table{"orders": {FileName: "orders.csv"}, "customers": {DerivedFrom: "orders"}}},
+		{name: "introspected", tables: map[string]Table{"orders": {FileName: "orders.csv"}, "customers": {}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbSchema := DB{Tables: tt.tables}
+			if err := dbSchema.requireSources("sql"); (err != nil) != tt.wantErr {
+				t.Errorf("DB.requireSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_inferSchema_headerless(t *testing.T) {
+	basePath := t.TempDir()
+	files := map[string]string{
+		"people.csv":     "1,Ram\n2,Shyam\n",
+		"population.csv": "city,count\nDelhi,100\n",
+	}
+	for fileName, data := range files {
+		if err := os.WriteFile(filepath.Join(basePath, fileName), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parseOptions := defaultParseOptions()
+	if err := parseOptions.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	existingTables := map[string]Table{"people": {TableName: "people", FileName: "people.csv", Headerless: true}}
+	dbSchema, err := inferSchema(basePath, &parseOptions, defaultNamingOptions(), existingTables)
+	if err != nil {
+		t.Fatalf("inferSchema() error = %v", err)
+	}
+
+	// the first row of people is data, population keeps its header row
+	people := dbSchema.Tables["people"]
+	if !reflect.DeepEqual(people.Headers, []string{"col_1", "col_2"}) || people.Columns["col_1"].DataType != "integer" {
+		t.Errorf("inferSchema() people = %v, %+v", people.Headers, people.Columns)
+	}
+
+	population := dbSchema.Tables["population"]
+	if len(population.Headers) != 0 || population.Columns["count"].DataType != "integer" {
+		t.Errorf("inferSchema() population = %v, %+v", population.Headers, population.Columns)
+	}
+}
@@ -3,38 +3,53 @@ package main
 import (
 	"bufio"
 	"bytes"
-	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 )
 
+// rows hashed & recorded together in writeTableRows
+const insertionBatchSize = 1000
+
 type insertionResponse struct {
 	err   error
 	table *Table
 }
 
-func (dbSchema *DB) createStatements() (*bytes.Buffer, error) {
-	var createBuffer bytes.Buffer
-
-	basePath, err := os.Getwd()
-	if err != nil {
-		return &createBuffer, err
-	}
-
-	funcs := template.FuncMap{
+// returns the functions used in sql.tmpl
+func getSQLTemplateFuncs(options *SQLOptions) template.FuncMap {
+	return template.FuncMap{
 		"HasSuffix":                strings.HasSuffix,
 		"TrimSuffix":               strings.TrimSuffix,
+		"baseType":                 baseType,
 		"templateValue":            templateValue,
+		"templateColumnValue":      templateColumnValue,
 		"decrease":                 decrease,
 		"getArrayValidatorArgs":    getArrayValidatorArgs,
 		"templateCheckConstraints": templateCheckConstraints,
+		"templateTableChecks":      templateTableChecks,
+		"templateIDColumn":         templateIDColumn,
+		"templateComment":          templateComment,
+		"getSQLOptions":            func() SQLOptions { return *options },
+	}
+}
+
+func (dbSchema *DB) createStatements(options *SQLOptions) (*bytes.Buffer, error) {
+	var createBuffer bytes.Buffer
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		return &createBuffer, err
 	}
 
+	funcs := getSQLTemplateFuncs(options)
+
 	fileName := "sql.tmpl"
 	templatePath := filepath.Join(basePath, "templates", fileName)
 
@@ -46,6 +61,22 @@ func (dbSchema *DB) createStatements() (*bytes.Buffer, error) {
 
 	writer := bufio.NewWriter(&createBuffer)
 
+	// SEQUENCES & UUID v7
+	if err := template.ExecuteTemplate(writer, "Sequences", dbSchema.getSequences()); err != nil {
+		return &createBuffer, err
+	}
+
+	if dbSchema.usesUUIDv7() {
+		if err := template.ExecuteTemplate(writer, "uuid_v7_function", nil); err != nil {
+			return &createBuffer, err
+		}
+	}
+
+	// ENUM TYPES & LOOKUP TABLES
+	if err := template.ExecuteTemplate(writer, "Enums", dbSchema.Enums); err != nil {
+		return &createBuffer, err
+	}
+
 	// TABLES
 	if err := template.ExecuteTemplate(writer, "Tables", dbSchema.Tables); err != nil {
 		return &createBuffer, err
@@ -55,15 +86,11 @@ func (dbSchema *DB) createStatements() (*bytes.Buffer, error) {
 	datatypes := map[string]bool{}
 	for _, table := range dbSchema.Tables {
 		for _, column := range table.Columns {
-			datatype := column.DataType
-			isArray := strings.HasSuffix(datatype, "[]")
-			datatype = strings.TrimSuffix(datatype, "[]")
-
-			if isArray && !datatypes[datatype] && (column.minArrLen > 0 ||
-				column.maxArrLen > 0 ||
-				column.minIndividual != nil ||
-				column.maxIndividual != nil ||
-				len(column.Enums) > 0) {
+			// numeric(p,s)[] arrays share the numeric validator
+			isArray := strings.HasSuffix(column.DataType, "[]")
+			datatype := baseType(column.DataType)
+
+			if isArray && !datatypes[datatype] && getArrayValidatorArgs(column) != "" {
 				if err := template.ExecuteTemplate(writer, "array_validator_function", datatype); err != nil {
 					return &createBuffer, err
 				}
@@ -84,7 +111,7 @@ func (dbSchema *DB) createStatements() (*bytes.Buffer, error) {
 	return &createBuffer, nil
 }
 
-func (dbSchema *DB) foreignKeyStatements() (*bytes.Buffer, error) {
+func (dbSchema *DB) foreignKeyStatements(options *SQLOptions) (*bytes.Buffer, error) {
 	var foreignBuffer bytes.Buffer
 
 	basePath, err := os.Getwd()
@@ -94,14 +121,7 @@ func (dbSchema *DB) foreignKeyStatements() (*bytes.Buffer, error) {
 
 	fileName := "sql.tmpl"
 
-	funcs := template.FuncMap{
-		"HasSuffix":                strings.HasSuffix,
-		"TrimSuffix":               strings.TrimSuffix,
-		"templateValue":            templateValue,
-		"decrease":                 decrease,
-		"getArrayValidatorArgs":    getArrayValidatorArgs,
-		"templateCheckConstraints": templateCheckConstraints,
-	}
+	funcs := getSQLTemplateFuncs(options)
 
 	templatePath := filepath.Join(basePath, "templates", fileName)
 
@@ -124,22 +144,24 @@ func (dbSchema *DB) foreignKeyStatements() (*bytes.Buffer, error) {
 	return &foreignBuffer, nil
 }
 
-func (dbSchema *DB) dataInsertion() (*bytes.Buffer, error) {
+// returns the INSERT statements of all the tables along with the summary of rejected rows
+// invalid rows abort the insertion unless options.Reject is set
+func (dbSchema *DB) dataInsertion(options *SQLOptions) (*bytes.Buffer, []string, error) {
 	var insertionBuffer bytes.Buffer
 
 	responseChannel := make(chan insertionResponse, 4)
 	tableCount := len(dbSchema.Tables)
 
+	pool := newHashPool(options.HashWorkers)
+
 	for _, table := range dbSchema.Tables {
-		writer := bufio.NewWriter(&insertionBuffer)
-		filePath := filepath.Join(dbSchema.BasePath, table.FileName)
-		go writeTableRows(filePath, &table, writer, responseChannel)
+		go writeTableRows(dbSchema.BasePath, &table, options, pool, responseChannel)
 	}
 
 	for response := range responseChannel {
 		tableCount--
 		if response.err != nil {
-			return &insertionBuffer, response.err
+			return &insertionBuffer, nil, response.err
 		}
 		table := response.table
 		dbSchema.Tables[table.TableName] = *table
@@ -148,83 +170,158 @@ func (dbSchema *DB) dataInsertion() (*bytes.Buffer, error) {
 		}
 	}
 
-	if err := validateForeignValues(dbSchema.Tables); err != nil {
+	pool.close()
+
+	if err := validateForeignValues(dbSchema.Tables, options); err != nil {
 		errorMessage := fmt.Sprintf("error while validating foreign values: %v", err)
-		return &insertionBuffer, errors.New(errorMessage)
+		return &insertionBuffer, nil, errors.New(errorMessage)
+	}
+
+	report := dbSchema.dedupReport()
+	if options.Reject {
+		rejects, err := dbSchema.writeRejects(options)
+		report = append(report, rejects...)
+		if err != nil {
+			return &insertionBuffer, report, err
+		}
+	}
+
+	for _, tableName := range slices.Sorted(maps.Keys(dbSchema.Tables)) {
+		table := dbSchema.Tables[tableName]
+		table.writeInsertion(&insertionBuffer, options)
 	}
 
-	return &insertionBuffer, nil
+	return &insertionBuffer, report, nil
 }
 
-func writeTableRows(filePath string, table *Table, writer *bufio.Writer, channel chan<- insertionResponse) {
+// reads & validates the rows of the table, rows are validated one by one but hashed in batches
+// invalid rows are recorded as rejects if options.Reject is set
+func writeTableRows(basePath string, table *Table, options *SQLOptions, pool *hashPool, channel chan<- insertionResponse) {
 	tableName := table.TableName
 	var mainError error
 
-	fp, err := os.Open(filePath)
+	reader, err := table.openRows(basePath)
 	if err != nil {
 		channel <- insertionResponse{table: table, err: err}
 		return
 	}
 
 	defer func() {
-		fp.Close()
+		reader.Close()
 		channel <- insertionResponse{table: table, err: mainError}
 	}()
 
-	reader := csv.NewReader(fp)
-
-	headersSQL := ""
 	headers, err := reader.Read()
 	if err != nil {
 		mainError = err
 		return
 	}
 
+	rows := &tableRows{labels: slices.Clone(headers)}
+	table.rows = rows
+
+	originals := make(map[string]string, len(headers)) // key: column name, value: header
 	for idx, header := range headers {
-		arr := strings.SplitN(header, ":", 2)
-		if len(arr) == 2 {
-			header = arr[1]
+		columnName, ok := table.headerColumn(header)
+		if !ok {
+			errorMessage := fmt.Sprintf("%s column not found in %s table schema", headerLabel(header), tableName)
+			mainError = errors.New(errorMessage)
+			return
 		}
 
-		columnName := sanitize_db_label(header)
+		if original, ok := originals[columnName]; ok {
+			mainError = fmt.Errorf("headers %q and %q of %s both map to column %s", original, header, table.sourceName(), columnName)
+			return
+		}
 
-		if _, ok := table.Columns[columnName]; !ok {
-			errorMessage := fmt.Sprintf("%s column not found in %s table schema", header, tableName)
-			mainError = errors.New(errorMessage)
+		if table.Columns[columnName].Expr != "" {
+			mainError = fmt.Errorf("header %q of %s maps to computed column %s", header, table.sourceName(), columnName)
 			return
 		}
 
+		originals[columnName] = header
 		headers[idx] = columnName
+	}
+	headers = append(headers, table.computedColumns()...)
+	rows.headers = headers
 
-		headersSQL += fmt.Sprintf(`"%s"`, columnName)
-		if idx < len(headers)-1 {
-			headersSQL += ", "
-		}
+	dedup, err := newDeduper(basePath, table, headers, options.Reject)
+	if err != nil {
+		mainError = err
+		return
+	}
+
+	hashEnabled := false
+	for _, column := range table.Columns {
+		hashEnabled = hashEnabled || column.Hash
 	}
 
 	rowIdx := 2
+	batch := make([][]any, 0, insertionBatchSize)
+	batchFields := make([][]string, 0, insertionBatchSize)
+	batchRows := make([]int, 0, insertionBatchSize) // row no. of batch rows
+
+	// hashes the pending rows (if required) and records them
+	flushBatch := func() error {
+		if hashEnabled {
+			if err := pool.hashRows(batch, batchRows, headers, table); err != nil {
+				return err
+			}
+		}
 
-	for {
-		row, err := reader.Read()
-		if err == io.EOF {
-			if rowIdx > 2 {
-				writer.Write([]byte(";\n"))
+		for batchIdx, values := range batch {
+			row := insertRow{rowIdx: batchRows[batchIdx]}
+			if options.Reject {
+				row.fields = batchFields[batchIdx]
+			}
+
+			var builder strings.Builder
+			builder.WriteString("(")
+
+			for idx, val := range values {
+				column := table.Columns[headers[idx]]
+				str := templateColumnValue(column, val)
+				if val == nil && column.IsGenerated() {
+					str = "DEFAULT"
+				}
+
+				if len(column.ForeignField) > 0 && str != "NULL" {
+					column.lookup[str] = append(column.lookup[str], row.rowIdx)
+				}
+
+				// rows without a primary key value get a generated one
+				if column.ColumnName == table.PrimaryKey && val != nil {
+					row.key = templateValue(val, column.DataType)
+				}
+
+				if idx < len(headers)-1 {
+					str += ", "
+				}
+
+				builder.WriteString(str)
 			}
-			break
+
+			builder.WriteString(table.lineageValues(row.rowIdx))
+			builder.WriteString(")")
+			row.sql = builder.String()
+			rows.rows = append(rows.rows, row)
 		}
 
-		if rowIdx == 2 {
-			text := fmt.Sprintf("-- DATA INSERTION \"%s\"\n", tableName)
-			text += fmt.Sprintf("INSERT INTO \"%s\" (%s)\nVALUES\n", tableName, headersSQL)
+		batch, batchFields, batchRows = batch[:0], batchFields[:0], batchRows[:0]
+		return nil
+	}
 
-			if _, err := writer.Write([]byte(text)); err != nil {
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			if err := flushBatch(); err != nil {
 				mainError = err
 				return
 			}
-		}
 
-		if rowIdx > 2 {
-			writer.Write([]byte(",\n"))
+			rows.dropped = dedup.dropped
+			mainError = dedup.err()
+			return
 		}
 
 		if err != nil {
@@ -232,9 +329,28 @@ func writeTableRows(filePath string, table *Table, writer *bufio.Writer, channel
 			return
 		}
 
-		if _, err := writer.Write([]byte("(")); err != nil {
-			mainError = err
-			return
+		rows.total++
+
+		values := make([]any, len(headers))
+		unique := []int{} // indexes of the unique values recorded for the row
+		reason := ""
+
+		row, err := table.transformRow(headers, fields)
+		if err == nil {
+			var admitted bool
+			if fields, row, admitted, err = dedup.admit(rowIdx, fields, row); !admitted && err == nil {
+				rowIdx++
+				continue
+			}
+		}
+
+		if err != nil {
+			if !options.Reject {
+				mainError = fmt.Errorf("error in %s of %s table: %v", table.rowLocation(rowIdx), tableName, err)
+				return
+			}
+
+			reason = err.Error()
 		}
 
 		for idx, value := range row {
@@ -243,70 +359,102 @@ func writeTableRows(filePath string, table *Table, writer *bufio.Writer, channel
 			val, err := column.validateValueByConstraints(value, true)
 
 			if err != nil {
-				errorMessage := fmt.Sprintf("error in row no. %d in %s column of %s table: %v", rowIdx, columnName, tableName, err)
-				mainError = errors.New(errorMessage)
-				return
-			}
-
-			if column.Hash && val != nil {
-				hashedVal, err := hashText(val, column.DataType)
-
-				if err != nil {
-					errorMessage := fmt.Sprintf("error in row no. %d in %s column of %s table: %v", rowIdx, columnName, tableName, err)
+				if !options.Reject {
+					errorMessage := fmt.Sprintf("error in %s in %s column of %s table: %v", table.rowLocation(rowIdx), columnName, tableName, err)
 					mainError = errors.New(errorMessage)
 					return
 				}
 
-				val = hashedVal
+				reason = fmt.Sprintf("%s column: %v", columnName, err)
+				break
 			}
 
-			str := templateValue(val, column.DataType)
-
-			if len(column.ForeignField) > 0 && str != "NULL" {
-				column.lookup[str] = rowIdx
+			if column.Unique && val != nil {
+				unique = append(unique, idx)
 			}
 
-			if idx < len(headers)-1 {
-				str += ", "
+			values[idx] = val
+			table.Columns[columnName] = column
+		}
+
+		if reason == "" {
+			if err := table.validateChecks(headers, values); err != nil {
+				if !options.Reject {
+					mainError = fmt.Errorf("error in %s of %s table: %v", table.rowLocation(rowIdx), tableName, err)
+					return
+				}
+
+				reason = err.Error()
 			}
+		}
 
-			if _, err := writer.Write([]byte(str)); err != nil {
-				mainError = err
-				return
+		if reason != "" {
+			for _, idx := range unique {
+				column := table.Columns[headers[idx]]
+				delete(column.values, templateValue(values[idx], column.DataType))
 			}
 
-			table.Columns[columnName] = column
+			rows.rejects = append(rows.rejects, rejectedRow{rowIdx: rowIdx, reason: reason, fields: fields})
+			rowIdx++
+			continue
 		}
 
-		if _, err := writer.Write([]byte(")")); err != nil {
-			mainError = err
-			return
-		}
+		dedup.accept(rowIdx)
+		batch = append(batch, values)
+		batchFields = append(batchFields, fields)
+		batchRows = append(batchRows, rowIdx)
 		rowIdx++
-	}
 
-	writer.Write([]byte("\n"))
-	if err := writer.Flush(); err != nil {
-		mainError = err
-		return
+		if len(batch) == insertionBatchSize {
+			if err := flushBatch(); err != nil {
+				mainError = err
+				return
+			}
+		}
 	}
 }
 
-func validateForeignValues(tables map[string]Table) error {
-	for tableName, table := range tables {
-		for columnName, column := range table.Columns {
-			if len(column.lookup) > 0 {
+// checks that the foreign key values are found in the referenced tables
+// with options.Reject, rows with missing values are rejected, repeated until the rejects of referenced tables settle
+func validateForeignValues(tables map[string]Table, options *SQLOptions) error {
+	for changed := true; changed; {
+		changed = false
+
+		for _, tableName := range slices.Sorted(maps.Keys(tables)) {
+			table := tables[tableName]
+
+			for _, columnName := range slices.Sorted(maps.Keys(table.Columns)) {
+				column := table.Columns[columnName]
+				if len(column.lookup) == 0 {
+					continue
+				}
+
 				foreignTable := tables[column.ForeignTable]
 				foreignColumn := foreignTable.Columns[column.ForeignField]
 
-				for key, rowNum := range column.lookup {
-					if !foreignColumn.values[key] {
-						errorMessage := fmt.Sprintf("invalid value %s for foreign key column %s in %s table on row num: %d", key, columnName, tableName, rowNum)
+				for _, key := range slices.Sorted(maps.Keys(column.lookup)) {
+					if foreignColumn.values[key] {
+						continue
+					}
+
+					rowNums := column.lookup[key]
+					if !options.Reject {
+						errorMessage := fmt.Sprintf("invalid value %s for foreign key column %s in %s table on %s", key, columnName, tableName, table.rowLocation(rowNums[0]))
 						return errors.New(errorMessage)
 					}
+
+					reason := fmt.Sprintf("%s column: value %s not found in %s column of %s table", columnName, key, column.ForeignField, column.ForeignTable)
+					for _, rowNum := range rowNums {
+						if table.rejectInserted(rowNum, reason) {
+							changed = true
+						}
+					}
+
+					delete(column.lookup, key)
 				}
 			}
 		}
 	}
+
 	return nil
 }
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// a statement of the sync transaction, kind tells the counts it returns
+//
+//	upsert: inserted & updated rows
+//	delete: deleted rows
+//	insert: inserted rows, via rows affected
+type syncStatement struct {
+	tableName string
+	kind      string // empty for statements without counts
+	sql       string
+}
+
+type syncCounts struct {
+	staged   int
+	inserted int
+	updated  int
+	deleted  int
+}
+
+// syncs the sources with the existing tables, matched by primary key
+// the statements are written to sync.sql or run in a single transaction with options.Apply
+func (dbSchema *DB) syncData(options *SyncOptions) ([]string, error) {
+	if options.Apply && options.Database == "" {
+		return nil, errors.New("database connection string is required to apply the sync")
+	}
+
+	_, report, err := dbSchema.dataInsertion(&SQLOptions{HashWorkers: options.HashWorkers})
+	if err != nil {
+		return report, err
+	}
+
+	statements, counts, skipped := dbSchema.syncStatements(options.Delete)
+	for _, tableName := range skipped {
+		report = append(report, fmt.Sprintf("%s table skipped, its primary key isn't read from the source", tableName))
+	}
+
+	if options.Apply {
+		if err := applySync(options.Database, statements, counts); err != nil {
+			return report, err
+		}
+	} else {
+		var buffer bytes.Buffer
+		for _, statement := range statements {
+			buffer.WriteString(statement.sql)
+			buffer.WriteString("\n")
+		}
+
+		if err := writeFile(filepath.Join(dbSchema.BasePath, "sync.sql"), wrapTransaction(&buffer)...); err != nil {
+			return report, fmt.Errorf("error while creating sync.sql: %v", err)
+		}
+	}
+
+	for _, tableName := range slices.Sorted(maps.Keys(counts)) {
+		count := counts[tableName]
+		if !options.Apply {
+			report = append(report, fmt.Sprintf("%s: %d rows staged", tableName, count.staged))
+			continue
+		}
+
+		line := fmt.Sprintf("%s: %d inserted, %d updated", tableName, count.inserted, count.updated)
+		if options.Delete {
+			line += fmt.Sprintf(", %d deleted", count.deleted)
+		}
+		report = append(report, line)
+	}
+
+	return report, nil
+}
+
+// returns the statements syncing the tables along with the staged rows per table & the skipped tables
+// all the rows are staged first, then upserted from referenced tables onwards & missing rows are deleted from referring tables onwards
+func (dbSchema *DB) syncStatements(deleteMissing bool) ([]syncStatement, map[string]*syncCounts, []string) {
+	order := dbSchema.tableOrder()
+	counts := map[string]*syncCounts{}
+	skipped := []string{}
+	var stage, remove, upsert []syncStatement
+
+	for _, tableName := range order {
+		table := dbSchema.Tables[tableName]
+		if table.rows == nil || table.PrimaryKey == "" || !slices.Contains(table.rows.headers, table.PrimaryKey) {
+			skipped = append(skipped, tableName)
+			continue
+		}
+
+		counts[tableName] = &syncCounts{}
+		stage = append(stage, table.stageStatements(counts[tableName])...)
+		upsert = append(upsert, table.upsertStatements()...)
+	}
+
+	if deleteMissing {
+		for _, tableName := range slices.Backward(order) {
+			if _, ok := counts[tableName]; ok {
+				table := dbSchema.Tables[tableName]
+				remove = append(remove, table.deleteStatement())
+			}
+		}
+	}
+
+	return slices.Concat(stage, upsert, remove), counts, skipped
+}
+
+// returns the table names such that referenced tables precede the ones referring to them
+// tables in a cycle of foreign keys follow alphabetically
+func (dbSchema *DB) tableOrder() []string {
+	names := slices.Sorted(maps.Keys(dbSchema.Tables))
+	order := make([]string, 0, len(names))
+	done := map[string]bool{}
+
+	for len(order) < len(names) {
+		progressed := false
+
+		for _, tableName := range names {
+			if done[tableName] || !dbSchema.referencesDone(tableName, done) {
+				continue
+			}
+
+			order, done[tableName], progressed = append(order, tableName), true, true
+		}
+
+		if !progressed {
+			for _, tableName := range names {
+				if !done[tableName] {
+					order, done[tableName] = append(order, tableName), true
+					break
+				}
+			}
+		}
+	}
+
+	return order
+}
+
+// true if all the tables referenced by tableName, other than itself, are done
+func (dbSchema *DB) referencesDone(tableName string, done map[string]bool) bool {
+	for _, column := range dbSchema.Tables[tableName].Columns {
+		foreignTable := column.ForeignTable
+		if _, ok := dbSchema.Tables[foreignTable]; ok && foreignTable != tableName && !done[foreignTable] {
+			return false
+		}
+	}
+	return true
+}
+
+// name of the staging table, truncated like other identifiers if the prefix makes it too long
+func (table *Table) stagingTable() string {
+	return NamingOptions{}.truncate("sync_" + table.TableName)
+}
+
+// returns the quoted column names of the insertion, including the lineage columns filled during insertion
+func (table *Table) quotedHeaders() string {
+	columnNames := append(slices.Clone(table.rows.headers), table.lineageHeaders()...)
+	quoted := make([]string, len(columnNames))
+	for idx, columnName := range columnNames {
+		quoted[idx] = fmt.Sprintf(`"%s"`, columnName)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// returns the statements creating & filling the staging table with the rows having a primary key value
+func (table *Table) stageStatements(counts *syncCounts) []syncStatement {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "-- SYNC \"%s\"\n", table.TableName)
+	fmt.Fprintf(&builder, "CREATE TEMP TABLE \"%s\" (LIKE \"%s\" INCLUDING DEFAULTS INCLUDING IDENTITY) ON COMMIT DROP;\n", table.stagingTable(), table.TableName)
+	statements := []syncStatement{{tableName: table.TableName, sql: builder.String()}}
+
+	values := []string{}
+	for _, row := range table.rows.rows {
+		if row.key != "" {
+			values = append(values, row.sql)
+		}
+	}
+
+	counts.staged = len(values)
+	if len(values) > 0 {
+		statements = append(statements, syncStatement{
+			tableName: table.TableName,
+			sql:       fmt.Sprintf("INSERT INTO \"%s\" (%s)\nVALUES\n%s;\n", table.stagingTable(), table.quotedHeaders(), strings.Join(values, ",\n")),
+		})
+	}
+
+	return statements
+}
+
+// returns the statement deleting the rows whose primary key isn't staged
+func (table *Table) deleteStatement() syncStatement {
+	query := fmt.Sprintf(`WITH deleted AS (
+	DELETE FROM "%s" AS t
+	WHERE NOT EXISTS (SELECT 1 FROM "%s" AS s WHERE s."%s" = t."%s")
+	RETURNING 1
+)
+SELECT '%s' AS "table", count(*) AS "deleted" FROM deleted;
+`, table.TableName, table.stagingTable(), table.PrimaryKey, table.PrimaryKey, table.TableName)
+
+	return syncStatement{tableName: table.TableName, kind: "delete", sql: query}
+}
+
+// returns the statements upserting the staged rows & inserting the rows without a primary key value
+func (table *Table) upsertStatements() []syncStatement {
+	headers := table.quotedHeaders()
+
+	// hashes are salted, hence the existing ones are kept
+	updated := []string{}
+	for _, columnName := range table.rows.headers {
+		if columnName != table.PrimaryKey && !table.Columns[columnName].Hash {
+			updated = append(updated, columnName)
+		}
+	}
+
+	conflict := "DO NOTHING"
+	if len(updated) > 0 {
+		set, existing, excluded := make([]string, len(updated)), make([]string, len(updated)), make([]string, len(updated))
+		for idx, columnName := range updated {
+			set[idx] = fmt.Sprintf(`"%s" = EXCLUDED."%s"`, columnName, columnName)
+			existing[idx] = fmt.Sprintf(`t."%s"`, columnName)
+			excluded[idx] = fmt.Sprintf(`EXCLUDED."%s"`, columnName)
+		}
+
+		// lineage of changed rows is updated, it doesn't count as a change itself
+		for _, columnName := range table.lineageHeaders() {
+			set = append(set, fmt.Sprintf(`"%s" = EXCLUDED."%s"`, columnName, columnName))
+		}
+		if table.lineage {
+			set = append(set, `"_loaded_at" = now()`)
+		}
+
+		conflict = fmt.Sprintf("DO UPDATE SET %s\n\tWHERE ROW(%s) IS DISTINCT FROM ROW(%s)",
+			strings.Join(set, ", "), strings.Join(existing, ", "), strings.Join(excluded, ", "))
+	}
+
+	query := fmt.Sprintf(`WITH upserted AS (
+	INSERT INTO "%s" AS t (%s)
+	SELECT %s FROM "%s"
+	ON CONFLICT ("%s") %s
+	RETURNING (xmax = 0) AS inserted
+)
+SELECT '%s' AS "table", count(*) FILTER (WHERE inserted) AS "inserted", count(*) FILTER (WHERE NOT inserted) AS "updated" FROM upserted;
+`, table.TableName, headers, headers, table.stagingTable(), table.PrimaryKey, conflict, table.TableName)
+
+	statements := []syncStatement{{tableName: table.TableName, kind: "upsert", sql: query}}
+
+	// rows without a primary key value get a generated one, hence always inserted
+	values := []string{}
+	for _, row := range table.rows.rows {
+		if row.key == "" {
+			values = append(values, row.sql)
+		}
+	}
+
+	if len(values) > 0 {
+		statements = append(statements, syncStatement{
+			tableName: table.TableName,
+			kind:      "insert",
+			sql:       fmt.Sprintf("INSERT INTO \"%s\" (%s)\nVALUES\n%s;\n", table.TableName, headers, strings.Join(values, ",\n")),
+		})
+	}
+
+	if reset := templateSequenceReset(table, table.rows.headers); reset != "" {
+		statements = append(statements, syncStatement{tableName: table.TableName, sql: reset})
+	}
+
+	return statements
+}
+
+// runs the statements in a single transaction & records the counts they return
+func applySync(connection string, statements []syncStatement, counts map[string]*syncCounts) error {
+	db, err := sql.Open("postgres", connection)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range statements {
+		count := counts[statement.tableName]
+		var tableName string
+
+		switch statement.kind {
+		case "upsert":
+			err = tx.QueryRow(statement.sql).Scan(&tableName, &count.inserted, &count.updated)
+		case "delete":
+			err = tx.QueryRow(statement.sql).Scan(&tableName, &count.deleted)
+		default:
+			var result sql.Result
+			if result, err = tx.Exec(statement.sql); err == nil && statement.kind == "insert" {
+				inserted, _ := result.RowsAffected()
+				count.inserted += int(inserted)
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("error while syncing %s table: %v", statement.tableName, err)
+		}
+	}
+
+	return tx.Commit()
+}
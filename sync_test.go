@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_tableOrder(t *testing.T) {
+	dbSchema := DB{Tables: map[string]Table{
+		"marks":    {Columns: map[string]Column{"roll": {ForeignTable: "students"}}},
+		"students": {Columns: map[string]Column{"course": {ForeignTable: "courses"}, "mentor": {ForeignTable: "students"}}},
+		"courses":  {Columns: map[string]Column{"id": {}}},
+		"a":        {Columns: map[string]Column{"b": {ForeignTable: "b"}}},
+		"b":        {Columns: map[string]Column{"a": {ForeignTable: "a"}}},
+	}}
+
+	want := []string{"courses", "students", "marks", "a", "b"}
+	if got := dbSchema.tableOrder(); !reflect.DeepEqual(got, want) {
+		t.Errorf("tableOrder() = %v, want %v", got, want)
+	}
+}
+
+func Test_syncStatements(t *testing.T) {
+	basePath := t.TempDir()
+	files := map[string]string{
+		"courses.csv":  "id\n1\n",
+		"students.csv": "roll,password,course\n1,secret,1\n,secret,1\n",
+		"logs.csv":     "message\nstarted\n",
+	}
+	for fileName, data := range files {
+		if err := os.WriteFile(filepath.Join(basePath, fileName), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dbSchema := DB{BasePath: basePath, Timezone: "UTC", Tables: map[string]Table{
+		"courses": {
+			TableName:  "courses",
+			FileName:   "courses.csv",
+			PrimaryKey: "id",
+			Columns:    map[string]Column{"id": {ColumnName: "id", DataType: "integer", NotNull: true, Unique: true}},
+		},
+		"students": {
+			TableName:          "students",
+			FileName:           "students.csv",
+			PrimaryKey:         "roll",
+			PrimaryKeyStrategy: "identity",
+			Columns: map[string]Column{
+				"roll":     {ColumnName: "roll", DataType: "integer"},
+				"password": {ColumnName: "password", DataType: "text", Hash: true},
+				"course":   {ColumnName: "course", DataType: "integer", ForeignTable: "courses", ForeignField: "id", OnUpdate: "CASCADE", OnDelete: "CASCADE"},
+			},
+		},
+		"logs": {
+			TableName: "logs",
+			FileName:  "logs.csv",
+			Columns:   map[string]Column{"message": {ColumnName: "message", DataType: "text"}},
+		},
+	}}
+
+	if err := dbSchema.validateSchema(); err != nil {
+		t.Fatalf("validateSchema() error = %v", err)
+	}
+	if _, _, err := dbSchema.dataInsertion(&SQLOptions{HashWorkers: 1}); err != nil {
+		t.Fatalf("dataInsertion() error = %v", err)
+	}
+
+	statements, counts, skipped := dbSchema.syncStatements(true)
+
+	if !reflect.DeepEqual(skipped, []string{"logs"}) {
+		t.Errorf("syncStatements() skipped = %v, want [logs]", skipped)
+	}
+	if counts["students"].staged != 1 || counts["courses"].staged != 1 {
+		t.Errorf("syncStatements() staged = %d, %d, want 1, 1", counts["courses"].staged, counts["students"].staged)
+	}
+
+	if !strings.Contains(statements[0].sql, "INCLUDING IDENTITY") {
+		t.Errorf("syncStatements() staging = %s, want identity of the primary key", statements[0].sql)
+	}
+
+	kinds := []string{}
+	for _, statement := range statements {
+		if statement.kind != "" {
+			kinds = append(kinds, statement.tableName+" "+statement.kind)
+		}
+	}
+
+	want := []string{"courses upsert", "students upsert", "students insert", "students delete", "courses delete"}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("syncStatements() = %v, want %v", kinds, want)
+	}
+
+	for _, statement := range statements {
+		if statement.kind != "upsert" {
+			continue
+		}
+
+		switch statement.tableName {
+		case "courses":
+			if !strings.Contains(statement.sql, `ON CONFLICT ("id") DO NOTHING`) {
+				t.Errorf("courses upsert = %s", statement.sql)
+			}
+		case "students":
+			// hashes aren't updated
+			if !strings.Contains(statement.sql, `DO UPDATE SET "course" = EXCLUDED."course"`) || !strings.Contains(statement.sql, `WHERE ROW(t."course") IS DISTINCT FROM ROW(EXCLUDED."course")`) {
+				t.Errorf("students upsert = %s", statement.sql)
+			}
+		}
+	}
+}
+
+func Test_stagingTable(t *testing.T) {
+	short := Table{TableName: "students"}
+	if got := short.stagingTable(); got != "sync_students" {
+		t.Errorf("stagingTable() = %s, want sync_students", got)
+	}
+
+	long := Table{TableName: strings.Repeat("enrolment_", 6)}
+	longer := Table{TableName: long.TableName + "x"}
+	if got := long.stagingTable(); len(got) > maxIdentifierLen || got == longer.stagingTable() {
+		t.Errorf("stagingTable() = %s, want at most %d bytes & distinct names", got, maxIdentifierLen)
+	}
+}
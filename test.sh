@@ -1,5 +1,4 @@
-psql -h localhost -U postgres -c 'DROP DATABASE "CSV_App"'
-rm -r ./app
+rm -rf ./app
 
 #!/bin/bash
 set -e
@@ -12,8 +11,8 @@ cp ./data/appConfig.json ./data/appBackup.json
 ./CSV_App schema
 read -p "Press enter after reviewing schema.json"
 
-./CSV_App sql
-psql -h localhost -U postgres -c 'CREATE DATABASE "CSV_App"'
+./CSV_App sql --drop-existing
+psql -h localhost -U postgres -tc "SELECT 1 FROM pg_database WHERE datname = 'CSV_App'" | grep -q 1 || psql -h localhost -U postgres -c 'CREATE DATABASE "CSV_App"'
 psql -h localhost -U postgres -d "CSV_App" -f data/db.sql
 
 read -p "Press enter after reviewing appConfig.json"
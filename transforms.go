@@ -0,0 +1,581 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Transforms (Column.Transforms) rewrite the source values of a column in order before validation, e.g.
+
+	{"type": "trim"}
+	{"type": "upper"}, {"type": "lower"} or {"type": "title"}
+	{"type": "replace", "pattern": "\\s+", "with": " "}
+	{"type": "map", "values": {"CSE": "branch_1"}}
+	{"type": "defaultIfEmpty", "with": "unknown"}
+
+Computed columns (Column.Expr) aren't read from the source, they are evaluated over the transformed values of the row:
+
+	concat   := sum {"||" sum}
+	sum      := product {("+" | "-") product}
+	product  := value {("*" | "/") value}
+	value    := column | "quoted column" | number | 'text' | NULL | function "(" [concat {"," concat}] ")" | "(" concat ")"
+	function := upper(s) | lower(s) | trim(s) | length(s) | split(s, separator, n) | substr(s, start[, count])
+	          | replace(s, 'pattern', with) | coalesce(a, b, ...)
+
+Like SQL, empty values are NULL & make the result NULL except in coalesce, arithmetic requires numbers.
+*/
+
+var transformTypes = []string{"trim", "upper", "lower", "title", "replace", "map", "defaultIfEmpty"}
+
+// function name along with the min & max count of arguments, -1 indicates no max
+var exprFunctions = map[string][2]int{
+	"upper":    {1, 1},
+	"lower":    {1, 1},
+	"trim":     {1, 1},
+	"length":   {1, 1},
+	"split":    {3, 3},
+	"substr":   {2, 3},
+	"replace":  {3, 3},
+	"coalesce": {1, -1},
+}
+
+type columnTransform struct {
+	Transform
+	regex *regexp.Regexp // compiled Pattern of replace
+}
+
+// compiles the transforms & computed column expressions of all the columns
+func (table *Table) setTransforms() error {
+	for _, columnName := range slices.Sorted(maps.Keys(table.Columns)) {
+		column := table.Columns[columnName]
+
+		if err := column.setTransforms(); err != nil {
+			return fmt.Errorf("invalid transforms for column %s: %v", columnName, err)
+		}
+
+		column.Expr = strings.TrimSpace(column.Expr)
+		column.expr = nil
+		table.Columns[columnName] = column
+	}
+
+	for _, columnName := range table.computedColumns() {
+		column := table.Columns[columnName]
+
+		if slices.Contains(table.Headers, columnName) {
+			return fmt.Errorf("computed column %s can't be a header", columnName)
+		}
+
+		root, err := parseExpr(column.Expr)
+		if err != nil {
+			return fmt.Errorf("invalid expr for column %s: %v", columnName, err)
+		}
+
+		if err := root.resolveExpr(table.Columns); err != nil {
+			return fmt.Errorf("invalid expr for column %s: %v", columnName, err)
+		}
+
+		column.expr = root
+		table.Columns[columnName] = column
+	}
+
+	return nil
+}
+
+func (column *Column) setTransforms() error {
+	column.transforms = make([]columnTransform, 0, len(column.Transforms))
+
+	for idx, transform := range column.Transforms {
+		compiled := columnTransform{Transform: transform}
+
+		switch transform.Type {
+		case "replace":
+			regex, err := regexp.Compile(transform.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern of transform no. %d: %v", idx+1, err)
+			}
+			compiled.regex = regex
+		case "map":
+			if len(transform.Values) == 0 {
+				return fmt.Errorf("transform no. %d has no values to map", idx+1)
+			}
+		default:
+			if !slices.Contains(transformTypes, transform.Type) {
+				return fmt.Errorf("invalid type %s of transform no. %d, should be one of %v", transform.Type, idx+1, transformTypes)
+			}
+		}
+
+		column.transforms = append(column.transforms, compiled)
+	}
+
+	return nil
+}
+
+func (transform *columnTransform) apply(value string) string {
+	switch transform.Type {
+	case "trim":
+		return strings.TrimSpace(value)
+	case "upper":
+		return strings.ToUpper(value)
+	case "lower":
+		return strings.ToLower(value)
+	case "title":
+		return titleCase(value)
+	case "replace":
+		return transform.regex.ReplaceAllString(value, transform.With)
+	case "map":
+		if mapped, ok := transform.Values[value]; ok {
+			return mapped
+		}
+	case "defaultIfEmpty":
+		if strings.TrimSpace(value) == "" {
+			return transform.With
+		}
+	}
+	return value
+}
+
+// upper cases the first letter of each word & lower cases the rest, e.g. RAM kumar -> Ram Kumar
+func titleCase(value string) string {
+	runes := []rune(value)
+	for idx, char := range runes {
+		if idx == 0 || !unicode.IsLetter(runes[idx-1]) {
+			runes[idx] = unicode.ToUpper(char)
+		} else {
+			runes[idx] = unicode.ToLower(char)
+		}
+	}
+	return string(runes)
+}
+
+// returns the sorted names of computed columns
+func (table *Table) computedColumns() []string {
+	columns := []string{}
+	for _, columnName := range slices.Sorted(maps.Keys(table.Columns)) {
+		if strings.TrimSpace(table.Columns[columnName].Expr) != "" {
+			columns = append(columns, columnName)
+		}
+	}
+	return columns
+}
+
+// copies the transforms & computed columns of the existing table to the inferred one
+// returns headers along with the computed columns, their types are inferred from the computed values
+func (table *Table) copyTransforms(existing *Table, headers []string) ([]string, error) {
+	for _, columnName := range headers {
+		existingColumn, ok := existing.Columns[columnName]
+		if !ok {
+			continue
+		}
+
+		if strings.TrimSpace(existingColumn.Expr) != "" {
+			return nil, fmt.Errorf("header of %s maps to computed column %s", table.sourceName(), columnName)
+		}
+
+		column := table.Columns[columnName]
+		column.Transforms = existingColumn.Transforms
+		table.Columns[columnName] = column
+	}
+
+	for _, columnName := range existing.computedColumns() {
+		existingColumn := existing.Columns[columnName]
+		table.Columns[columnName] = Column{
+			ColumnName: columnName,
+			Label:      existingColumn.Label,
+			Transforms: existingColumn.Transforms,
+			Expr:       existingColumn.Expr,
+		}
+		headers = append(headers, columnName)
+	}
+
+	if err := table.setTransforms(); err != nil {
+		return nil, err
+	}
+
+	return headers, nil
+}
+
+// applies the transforms to the source fields of row & appends the values of computed columns
+// headers are the column names of the result, computed columns follow the source fields
+func (table *Table) transformRow(headers []string, row []string) ([]string, error) {
+	if len(headers) == len(row) && !table.hasTransforms(headers) {
+		return row, nil
+	}
+
+	result := make([]string, len(headers))
+	copy(result, row)
+
+	values := make(map[string]string, len(headers))
+	for idx := range row {
+		column := table.Columns[headers[idx]]
+		for _, transform := range column.transforms {
+			result[idx] = transform.apply(result[idx])
+		}
+		values[headers[idx]] = result[idx]
+	}
+
+	for idx := len(row); idx < len(headers); idx++ {
+		column := table.Columns[headers[idx]]
+
+		value, err := column.expr.evaluateExpr(values)
+		if err != nil {
+			return nil, fmt.Errorf("%s column: %v", column.ColumnName, err)
+		}
+
+		for _, transform := range column.transforms {
+			value = transform.apply(value)
+		}
+		result[idx] = value
+	}
+
+	return result, nil
+}
+
+func (table *Table) hasTransforms(headers []string) bool {
+	for _, columnName := range headers {
+		if len(table.Columns[columnName].transforms) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func parseExpr(expression string) (*checkNode, error) {
+	tokens, err := tokenizeCheck(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("empty expression")
+	}
+
+	parser := &checkParser{tokens: tokens}
+	root, err := parser.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.pos < len(tokens) {
+		return nil, fmt.Errorf("unexpected %s", parser.peek().value)
+	}
+
+	return root, nil
+}
+
+func (parser *checkParser) isArithmetic(operators ...string) bool {
+	token := parser.peek()
+	return token.kind == "arithmetic" && slices.Contains(operators, token.value)
+}
+
+func (parser *checkParser) parseConcat() (*checkNode, error) {
+	node, err := parser.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.isArithmetic("||") {
+		parser.next()
+		right, err := parser.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		node = &checkNode{kind: "arithmetic", operator: "||", children: []*checkNode{node, right}}
+	}
+
+	return node, nil
+}
+
+func (parser *checkParser) parseSum() (*checkNode, error) {
+	node, err := parser.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator := ""
+		switch token := parser.peek(); {
+		case parser.isArithmetic("+", "-"):
+			operator = parser.next().value
+		case token.kind == "number" && strings.HasPrefix(token.value, "-"): // a -1 is a - 1
+			parser.tokens[parser.pos].value = token.value[1:]
+			operator = "-"
+		default:
+			return node, nil
+		}
+
+		right, err := parser.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		node = &checkNode{kind: "arithmetic", operator: operator, children: []*checkNode{node, right}}
+	}
+}
+
+func (parser *checkParser) parseProduct() (*checkNode, error) {
+	node, err := parser.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.isArithmetic("*", "/") {
+		operator := parser.next().value
+		right, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node = &checkNode{kind: "arithmetic", operator: operator, children: []*checkNode{node, right}}
+	}
+
+	return node, nil
+}
+
+func (parser *checkParser) parseValue() (*checkNode, error) {
+	token := parser.next()
+
+	switch {
+	case token.kind == "(":
+		node, err := parser.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+
+		if parser.next().kind != ")" {
+			return nil, errors.New("missing )")
+		}
+
+		return node, nil
+	case token.kind == "identifier" && parser.peek().kind == "(":
+		parser.next()
+		node := &checkNode{kind: "function", name: strings.ToLower(token.value)}
+
+		for parser.peek().kind != ")" {
+			if len(node.children) > 0 {
+				if parser.next().kind != "," {
+					return nil, fmt.Errorf("expected , or ) in arguments of %s", token.value)
+				}
+			}
+
+			argument, err := parser.parseConcat()
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, argument)
+		}
+		parser.next()
+
+		return node, nil
+	case token.kind == "identifier":
+		return &checkNode{kind: "column", name: token.value}, nil
+	case token.kind == "number" || token.kind == "text":
+		return &checkNode{kind: "literal", literal: token.kind, raw: token.value}, nil
+	case token.kind == "keyword" && token.value == "NULL":
+		return &checkNode{kind: "literal", literal: "null"}, nil
+	case token.kind == "":
+		return nil, errors.New("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %s", token.value)
+	}
+}
+
+// checks the columns & functions of a computed column expression, columns should be read from the source
+func (node *checkNode) resolveExpr(columns map[string]Column) error {
+	switch node.kind {
+	case "column":
+		column, ok := columns[node.name]
+		if !ok {
+			return fmt.Errorf("unknown column %s", node.name)
+		}
+		if column.Expr != "" {
+			return fmt.Errorf("computed column %s can't be used in expressions", node.name)
+		}
+	case "literal":
+		if node.literal == "number" {
+			if _, err := strconv.ParseFloat(node.raw, 64); err != nil {
+				return fmt.Errorf("invalid number %s", node.raw)
+			}
+		}
+	case "function":
+		counts, ok := exprFunctions[node.name]
+		if !ok {
+			return fmt.Errorf("unknown function %s", node.name)
+		}
+
+		if len(node.children) < counts[0] || (counts[1] != -1 && len(node.children) > counts[1]) {
+			return fmt.Errorf("invalid number of arguments of %s", node.name)
+		}
+
+		if node.name == "replace" {
+			pattern := node.children[1]
+			if pattern.kind != "literal" || pattern.literal != "text" {
+				return errors.New("pattern of replace should be a 'text'")
+			}
+
+			regex, err := regexp.Compile(pattern.raw)
+			if err != nil {
+				return fmt.Errorf("invalid pattern of replace: %v", err)
+			}
+			node.value = regex
+		}
+	}
+
+	for _, child := range node.children {
+		if err := child.resolveExpr(columns); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evaluates a computed column expression, NULL is returned as empty string
+func (node *checkNode) evaluateExpr(values map[string]string) (string, error) {
+	value, err := node.exprValue(values)
+	if err != nil || value == nil {
+		return "", err
+	}
+	return *value, nil
+}
+
+// returns the value of the node, nil for NULL
+func (node *checkNode) exprValue(values map[string]string) (*string, error) {
+	switch node.kind {
+	case "column":
+		value := values[node.name]
+		if strings.TrimSpace(value) == "" {
+			return nil, nil
+		}
+		return &value, nil
+	case "literal":
+		if node.literal == "null" {
+			return nil, nil
+		}
+		return &node.raw, nil
+	case "function":
+		return node.functionValue(values)
+	}
+
+	// arithmetic & concatenation
+	left, err := node.children[0].exprValue(values)
+	if err != nil {
+		return nil, err
+	}
+	right, err := node.children[1].exprValue(values)
+	if err != nil || left == nil || right == nil {
+		return nil, err
+	}
+
+	if node.operator == "||" {
+		result := *left + *right
+		return &result, nil
+	}
+
+	a, errA := strconv.ParseFloat(strings.TrimSpace(*left), 64)
+	b, errB := strconv.ParseFloat(strings.TrimSpace(*right), 64)
+	if errA != nil || errB != nil {
+		return nil, fmt.Errorf("%s of %s and %s requires numbers", node.operator, *left, *right)
+	}
+
+	var number float64
+	switch node.operator {
+	case "+":
+		number = a + b
+	case "-":
+		number = a - b
+	case "*":
+		number = a * b
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("division of %s by zero", *left)
+		}
+		number = a / b
+	}
+
+	result := strconv.FormatFloat(number, 'f', -1, 64)
+	return &result, nil
+}
+
+func (node *checkNode) functionValue(values map[string]string) (*string, error) {
+	args := make([]*string, len(node.children))
+	for idx, child := range node.children {
+		value, err := child.exprValue(values)
+		if err != nil {
+			return nil, err
+		}
+		args[idx] = value
+	}
+
+	if node.name == "coalesce" {
+		for _, arg := range args {
+			if arg != nil {
+				return arg, nil
+			}
+		}
+		return nil, nil
+	}
+
+	if slices.Contains(args, nil) {
+		return nil, nil
+	}
+
+	text := *args[0]
+	var result string
+
+	switch node.name {
+	case "upper":
+		result = strings.ToUpper(text)
+	case "lower":
+		result = strings.ToLower(text)
+	case "trim":
+		result = strings.TrimSpace(text)
+	case "length":
+		result = strconv.Itoa(utf8.RuneCountInString(text))
+	case "replace":
+		result = node.value.(*regexp.Regexp).ReplaceAllString(text, *args[2])
+	case "split":
+		// n is 1-based, NULL if the part doesn't exist
+		n, err := strconv.Atoi(*args[2])
+		if err != nil {
+			return nil, fmt.Errorf("n of split should be an integer, found %s", *args[2])
+		}
+
+		parts := strings.Split(text, *args[1])
+		if *args[1] == " " {
+			parts = strings.Fields(text)
+		}
+		if n < 1 || n > len(parts) {
+			return nil, nil
+		}
+		result = parts[n-1]
+	case "substr":
+		// start is 1-based, like SQL
+		runes := []rune(text)
+		start, err := strconv.Atoi(*args[1])
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("start of substr should be a positive integer, found %s", *args[1])
+		}
+
+		end := len(runes)
+		if len(args) == 3 {
+			count, err := strconv.Atoi(*args[2])
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("count of substr should be a non-negative integer, found %s", *args[2])
+			}
+			end = min(end, start-1+count)
+		}
+
+		if start > end {
+			return nil, nil
+		}
+		result = string(runes[start-1 : end])
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_evaluateExpr(t *testing.T) {
+	columns := map[string]Column{
+		"full_name": {ColumnName: "full_name"},
+		"fee":       {ColumnName: "fee"},
+		"discount":  {ColumnName: "discount"},
+	}
+	values := map[string]string{"full_name": "Ram Kumar Singh", "fee": "1000", "discount": ""}
+
+	tests := []struct {
+		expression string
+		want       string
+		wantErr    string
+	}{
+		{expression: "split(full_name, ' ', 2)", want: "Kumar"},
+		{expression: "split(full_name, ' ', 4)", want: ""},
+		{expression: "upper(substr(full_name, 1, 3)) || '-' || fee", want: "RAM-1000"},
+		{expression: "fee-100 * 2", want: "800"},
+		{expression: "(fee - 100) / 3", want: "300"},
+		{expression: "fee - discount", want: ""},
+		{expression: "coalesce(discount, 0) + fee", want: "1000"},
+		{expression: `replace(full_name, '\s+', '_')`, want: "Ram_Kumar_Singh"},
+		{expression: "length(full_name)", want: "15"},
+		{expression: "full_name * 2", wantErr: "requires numbers"},
+		{expression: "fee / 0", wantErr: "by zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			root, err := parseExpr(tt.expression)
+			if err != nil {
+				t.Fatalf("parseExpr() error = %v", err)
+			}
+			if err := root.resolveExpr(columns); err != nil {
+				t.Fatalf("resolveExpr() error = %v", err)
+			}
+
+			got, err := root.evaluateExpr(values)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("evaluateExpr() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("evaluateExpr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTable_setTransforms(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns map[string]Column
+		wantErr string
+	}{
+		{name: "invalid type", columns: map[string]Column{"a": {Transforms: []Transform{{Type: "reverse"}}}}, wantErr: "invalid type reverse"},
+		{name: "invalid pattern", columns: map[string]Column{"a": {Transforms: []Transform{{Type: "replace", Pattern: "("}}}}, wantErr: "invalid pattern"},
+		{name: "unknown column", columns: map[string]Column{"a": {Expr: "upper(b)"}}, wantErr: "unknown column b"},
+		{name: "unknown function", columns: map[string]Column{"a": {Expr: "reverse(a)"}, "b": {}}, wantErr: "unknown function reverse"},
+		{name: "arguments", columns: map[string]Column{"a": {Expr: "split(b, ' ')"}, "b": {}}, wantErr: "invalid number of arguments of split"},
+		{name: "computed reference", columns: map[string]Column{"a": {Expr: "b"}, "b": {Expr: "c"}, "c": {}}, wantErr: "computed column b can't be used"},
+		{name: "valid", columns: map[string]Column{"a": {Expr: "lower(b) || c"}, "b": {}, "c": {}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &Table{TableName: "students", Columns: tt.columns}
+			err := table.setTransforms()
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Table.setTransforms() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTable_transformRow(t *testing.T) {
+	table := &Table{
+		TableName: "students",
+		Columns: map[string]Column{
+			"name":       {ColumnName: "name", Transforms: []Transform{{Type: "trim"}, {Type: "title"}}},
+			"branch":     {ColumnName: "branch", Transforms: []Transform{{Type: "map", Values: map[string]string{"CSE": "branch_1"}}}},
+			"grade":      {ColumnName: "grade", Transforms: []Transform{{Type: "defaultIfEmpty", With: "NA"}}},
+			"first_name": {ColumnName: "first_name", Expr: "split(name, ' ', 1)"},
+		},
+	}
+	if err := table.setTransforms(); err != nil {
+		t.Fatalf("Table.setTransforms() error = %v", err)
+	}
+
+	headers := append([]string{"name", "branch", "grade"}, table.computedColumns()...)
+	got, err := table.transformRow(headers, []string{" ram KUMAR ", "CSE", " "})
+	if err != nil {
+		t.Fatalf("Table.transformRow() error = %v", err)
+	}
+
+	want := []string{"Ram Kumar", "branch_1", "NA", "Ram"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Table.transformRow() = %q, want %q", got, want)
+	}
+}
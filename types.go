@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// width-aware integer types along with their bit sizes, all parsed as int64
+var integerBitSizes = map[string]int{
+	"smallint": 16,
+	"integer":  32,
+	"bigint":   64,
+}
+
+// max digits of the integer types, used to widen them into numeric(p,s)
+var integerDigits = map[string]int{
+	"smallint": 5,
+	"integer":  10,
+	"bigint":   19,
+}
+
+// max precision of Postgres numeric type
+const maxNumericPrecision = 1000
+
+// decimals with a fixed scale, e.g. 12.50, inferred as numeric(p,s) instead of real
+var decimalRegex = regexp.MustCompile(`^[+-]?(\d*)\.(\d+)$`)
+
+var numericTypeRegex = regexp.MustCompile(`^numeric(?:\((\d+)(?:,\s*(\d+))?\))?$`)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// returns the datatype without array suffixes & type modifiers, e.g. numeric(12,2)[] -> numeric
+func baseType(datatype string) string {
+	datatype = elementType(datatype)
+	if idx := strings.IndexByte(datatype, '('); idx != -1 {
+		return datatype[:idx]
+	}
+	return datatype
+}
+
+func isIntegerType(datatype string) bool {
+	_, ok := integerBitSizes[datatype]
+	return ok
+}
+
+// returns precision & scale of numeric(p,s), zero precision indicates unconstrained numeric
+func numericModifiers(datatype string) (int, int, bool) {
+	matches := numericTypeRegex.FindStringSubmatch(datatype)
+	if matches == nil {
+		return 0, 0, false
+	}
+
+	if matches[1] == "" {
+		return 0, 0, true
+	}
+
+	precision, _ := strconv.Atoi(matches[1])
+	scale := 0
+	if matches[2] != "" {
+		scale, _ = strconv.Atoi(matches[2])
+	}
+
+	if precision < 1 || precision > maxNumericPrecision || scale > precision {
+		return 0, 0, false
+	}
+
+	return precision, scale, true
+}
+
+// returns numeric(p,s) fitting the digits of a decimal, empty for other values
+func detectNumericType(value string) string {
+	matches := decimalRegex.FindStringSubmatch(value)
+	if matches == nil {
+		return ""
+	}
+
+	scale := len(matches[2])
+	precision := len(strings.TrimLeft(matches[1], "0")) + scale
+	if precision > maxNumericPrecision {
+		return ""
+	}
+
+	return fmt.Sprintf("numeric(%d,%d)", precision, scale)
+}
+
+// returns the integer digits & scale of numeric(p,s) or an integer type, integers fit any scale so it's -1
+func numberDigits(datatype string) (int, int, bool) {
+	if digits, ok := integerDigits[datatype]; ok {
+		return digits, -1, true
+	}
+
+	precision, scale, ok := numericModifiers(datatype)
+	if !ok || precision == 0 {
+		return 0, 0, false
+	}
+
+	return precision - scale, scale, true
+}
+
+// parses a decimal into its canonical text, rounded to the scale of numeric(p,s) like Postgres
+func parseNumeric(value, datatype string) (string, error) {
+	precision, scale, ok := numericModifiers(datatype)
+	if !ok {
+		return "", fmt.Errorf("invalid numeric type %s", datatype)
+	}
+
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok || strings.Contains(value, "/") {
+		return "", fmt.Errorf("%s isn't a decimal", value)
+	}
+
+	// unconstrained numeric keeps all the fractional digits
+	if precision == 0 {
+		ten := big.NewInt(10)
+		denominator := new(big.Int).Set(rat.Denom())
+		for scale < maxNumericPrecision && denominator.Cmp(big.NewInt(1)) != 0 {
+			gcd := new(big.Int).GCD(nil, nil, denominator, ten)
+			denominator.Quo(denominator, gcd)
+			scale++
+		}
+		return rat.FloatString(scale), nil
+	}
+
+	res := rat.FloatString(scale)
+
+	integerDigits := strings.TrimLeft(strings.SplitN(strings.TrimPrefix(res, "-"), ".", 2)[0], "0")
+	if len(integerDigits) > precision-scale {
+		return "", fmt.Errorf("%s overflows numeric(%d,%d)", value, precision, scale)
+	}
+
+	return res, nil
+}
+
+// compares canonical numeric texts
+func compareNumeric(a, b any) (int, bool) {
+	strA, ok1 := a.(string)
+	strB, ok2 := b.(string)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+
+	ratA, ok1 := new(big.Rat).SetString(strA)
+	ratB, ok2 := new(big.Rat).SetString(strB)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+
+	return ratA.Cmp(ratB), true
+}
+
+// returns the lowercase hyphenated form of uuid
+func parseUUID(value string) (string, error) {
+	if !uuidRegex.MatchString(value) {
+		return "", fmt.Errorf("%s isn't a uuid", value)
+	}
+	return strings.ToLower(value), nil
+}
+
+// returns the compact JSON with sorted keys, so equal documents have equal texts like jsonb
+func parseJSON(value string) (string, error) {
+	if !json.Valid([]byte(value)) {
+		return "", errors.New("invalid JSON")
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+
+	var parsed any
+	if err := decoder.Decode(&parsed); err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(parsed); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
@@ -12,15 +12,19 @@ import (
 	"strconv"
 	"strings"
 	"time"
-
-	"golang.org/x/crypto/bcrypt"
+	"unicode/utf8"
 )
 
 var basicTypes = map[string]interface{}{
-	"integer": int64(0),
-	"real":    float64(0),
-	"text":    "",
-	"boolean": false,
+	"smallint": int64(0),
+	"integer":  int64(0),
+	"bigint":   int64(0),
+	"real":     float64(0),
+	"numeric":  "", // canonical decimal text, see parseNumeric
+	"text":     "",
+	"uuid":     "",
+	"jsonb":    "", // canonical JSON text, see parseJSON
+	"boolean":  false,
 }
 
 var datetimeFormats = map[string]string{
@@ -36,11 +40,41 @@ var typeConversionFuncs = map[string]func(string) (any, error){
 		}
 		return fmt.Sprintf("%v", value), nil
 	},
+	"smallint": func(value string) (any, error) {
+		if len(value) == 0 {
+			return nil, nil
+		}
+		return strconv.ParseInt(value, 10, integerBitSizes["smallint"])
+	},
 	"integer": func(value string) (any, error) {
 		if len(value) == 0 {
 			return nil, nil
 		}
-		return strconv.ParseInt(value, 10, 64)
+		return strconv.ParseInt(value, 10, integerBitSizes["integer"])
+	},
+	"bigint": func(value string) (any, error) {
+		if len(value) == 0 {
+			return nil, nil
+		}
+		return strconv.ParseInt(value, 10, integerBitSizes["bigint"])
+	},
+	"numeric": func(value string) (any, error) {
+		if len(value) == 0 {
+			return nil, nil
+		}
+		return parseNumeric(value, "numeric")
+	},
+	"uuid": func(value string) (any, error) {
+		if len(value) == 0 {
+			return nil, nil
+		}
+		return parseUUID(value)
+	},
+	"jsonb": func(value string) (any, error) {
+		if len(value) == 0 {
+			return nil, nil
+		}
+		return parseJSON(value)
 	},
 	"real": func(value string) (any, error) {
 		if len(value) == 0 {
@@ -89,7 +123,17 @@ var typeConversionFuncs = map[string]func(string) (any, error){
 
 // used to validate Column.DataType
 func isValidTypeName(datatype string) bool {
-	datatype = strings.TrimSuffix(datatype, "[]")
+	dims := arrayDims(datatype)
+	datatype = elementType(datatype)
+
+	if dims > maxArrayDims || (dims > 0 && datatype == "jsonb") {
+		return false
+	}
+
+	if _, _, ok := numericModifiers(datatype); ok {
+		return true
+	}
+
 	_, isBasic := basicTypes[datatype]
 	_, isTime := datetimeFormats[datatype]
 	return isBasic || isTime
@@ -119,11 +163,11 @@ func (column *Column) setMinMaxConstraint() error {
 		}
 
 		if minLenInterface != nil {
-			column.minArrLen = minLenInterface.(int64)
+			column.minArrLen = int64(minLenInterface.(int))
 		}
 
 		if maxLenInterface != nil {
-			column.maxArrLen = maxLenInterface.(int64)
+			column.maxArrLen = int64(maxLenInterface.(int))
 		}
 
 		if column.minArrLen > column.maxArrLen {
@@ -141,13 +185,13 @@ func (column *Column) setMinMaxConstraint() error {
 			max = strings.TrimSpace(maxArr[1])
 		}
 
-		datatype = strings.TrimSuffix(datatype, "[]")
+		datatype = elementType(datatype)
 	}
 
 	// Individual Constraints
 
-	if datatype == "boolean" && (len(min) > 0 || len(max) > 0) {
-		return errors.New("individual boolean values can't have min/max constraints")
+	if (datatype == "boolean" || datatype == "uuid" || datatype == "jsonb") && (len(min) > 0 || len(max) > 0) {
+		return fmt.Errorf("individual %s values can't have min/max constraints", datatype)
 	}
 
 	if datatype == "text" {
@@ -164,13 +208,31 @@ func (column *Column) setMinMaxConstraint() error {
 		return errors.New("invalid max individual value")
 	}
 
+	// text lengths are compared as integers
+	if datatype == "positiveInt" {
+		datatype = "bigint"
+
+		if minInterface != nil {
+			minInterface = int64(minInterface.(int))
+		}
+
+		if maxInterface != nil {
+			maxInterface = int64(maxInterface.(int))
+		}
+	}
+
 	column.minIndividual = minInterface
 	column.maxIndividual = maxInterface
 
 	if minInterface != nil && maxInterface != nil {
-		if res, ok := compareTypeValues(minInterface, maxInterface, datatype); !ok || res == 1 {
+		res, ok := compareTypeValues(minInterface, maxInterface, datatype)
+		if !ok || res == 1 {
 			return errors.New("min value can't be greater than max value")
 		}
+
+		if res == 0 && (column.MinExclusive || column.MaxExclusive) {
+			return errors.New("exclusive min & max values can't be equal")
+		}
 	}
 
 	return nil
@@ -179,10 +241,10 @@ func (column *Column) setMinMaxConstraint() error {
 // Enums are validated against datatype, Min, Max constraints.
 // Individual elements are validated for array types
 func (column *Column) validateEnums() error {
-	datatype := strings.TrimSuffix(column.DataType, "[]")
+	datatype := elementType(column.DataType)
 
-	if len(column.Enums) > 25 {
-		return errors.New("array contains more than 25 values")
+	if column.EnumRef == "" && len(column.Enums) > 25 {
+		return errors.New("array contains more than 25 values, use enumRef for larger enums")
 	}
 
 	for idx, value := range column.Enums {
@@ -196,6 +258,10 @@ func (column *Column) validateEnums() error {
 		if err := column.validateValueByMinMax(interfaceVal); err != nil {
 			return err
 		}
+
+		if err := column.validateValueByPattern(interfaceVal); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -203,6 +269,7 @@ func (column *Column) validateEnums() error {
 
 // Returns true is Default value is nil or it satisfies the Min, Max & Enums constraints
 func (column *Column) validateDefaultValue() error {
+	column.defaultValue = nil
 	if column.Default == nil {
 		return nil
 	}
@@ -212,7 +279,7 @@ func (column *Column) validateDefaultValue() error {
 		return err
 	}
 
-	column.Default = interfaceVal
+	column.Default, column.defaultValue = interfaceVal, interfaceVal
 
 	return nil
 }
@@ -224,13 +291,37 @@ If length of value is 0, true is returned.
 Array types aren't supported.
 */
 func validateValueByType(value any, datatype string) (any, bool) {
+	// null array elements
+	if value == nil {
+		return nil, true
+	}
+
 	strVal := fmt.Sprintf("%v", value)
+
+	// JSON documents from schema.json are parsed by encoding/json
+	if _, isText := value.(string); !isText && value != nil && datatype == "jsonb" {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, false
+		}
+		strVal = string(encoded)
+	}
+
 	strVal = strings.TrimSpace(strVal)
 
 	if len(strVal) == 0 {
 		return nil, true
 	}
 
+	// numeric(p,s) is rounded to its scale
+	if baseType(datatype) == "numeric" && datatype != "numeric" {
+		parsed, err := parseNumeric(strVal, datatype)
+		if err != nil {
+			return nil, false
+		}
+		return parsed, true
+	}
+
 	convFunc, ok := typeConversionFuncs[datatype]
 	if !ok {
 		return nil, false
@@ -244,11 +335,11 @@ func validateValueByType(value any, datatype string) (any, bool) {
 	var parsed interface{}
 
 	switch datatype {
-	case "integer":
+	case "smallint", "integer", "bigint":
 		parsed, ok = convertedInterface.(int64)
 	case "real":
 		parsed, ok = convertedInterface.(float64)
-	case "text":
+	case "text", "numeric", "uuid", "jsonb":
 		parsed, ok = convertedInterface.(string)
 	case "boolean":
 		parsed, ok = convertedInterface.(bool)
@@ -276,33 +367,37 @@ func (column *Column) validateValueByConstraints(value any, insert bool) (any, e
 	if insert {
 		str := strings.TrimSpace(fmt.Sprintf("%v", value))
 
-		if len(str) == 0 || value == nil {
+		if len(str) == 0 || value == nil || column.parsing.isNull(str) {
+			// inserted as DEFAULT
+			if column.IsGenerated() {
+				return nil, nil
+			}
+
 			if column.NotNull {
 				return nil, errors.New("null values aren't allowed")
 			}
 
 			return column.Default, nil
 		}
+	}
 
-		if column.Unique {
-			if column.values[str] {
-				return nil, errors.New("unique constraint not satisfied")
-			}
-			templateVal := templateValue(str, column.DataType)
-			column.values[templateVal] = true
-		}
+	// CSV values are parsed with the input format
+	parse := validateValueByType
+	if insert {
+		parse = column.parseInput
 	}
 
 	if strings.HasSuffix(column.DataType, "[]") {
-		datatype := strings.TrimSuffix(column.DataType, "[]")
+		datatype := elementType(column.DataType)
 
 		interfaceArr, err := column.validateValArrLen(value)
 		if err != nil {
 			return nil, err
 		}
 
-		for idx, value := range interfaceArr {
-			interfaceVal, ok := validateValueByType(value, datatype)
+		// elements of nested arrays are validated individually
+		err = mapArray(interfaceArr, func(value any) (any, error) {
+			interfaceVal, ok := parse(value, datatype)
 			if !ok {
 				errorMessage := fmt.Sprintf("%v is not of %v datatype", value, column.DataType)
 				return nil, errors.New(errorMessage)
@@ -316,13 +411,26 @@ func (column *Column) validateValueByConstraints(value any, insert bool) (any, e
 				return nil, err
 			}
 
-			interfaceArr[idx] = interfaceVal
+			if err := column.validateValueByPattern(interfaceVal); err != nil {
+				return nil, err
+			}
+
+			return interfaceVal, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if insert {
+			if err := column.addUniqueValue(interfaceArr); err != nil {
+				return nil, err
+			}
 		}
 
 		return interfaceArr, nil
 	}
 
-	interfaceVal, ok := validateValueByType(value, column.DataType)
+	interfaceVal, ok := parse(value, column.DataType)
 	if !ok {
 		errorMessage := fmt.Sprintf("%v should be of %v type", value, column.DataType)
 		return nil, errors.New(errorMessage)
@@ -336,26 +444,45 @@ func (column *Column) validateValueByConstraints(value any, insert bool) (any, e
 		return nil, err
 	}
 
-	return interfaceVal, nil
-}
+	if err := column.validateValueByPattern(interfaceVal); err != nil {
+		return nil, err
+	}
 
-// receives string or []any array and checks the array min & max length constraints
-// also typecastes the array value into an array interface
-func (column *Column) validateValArrLen(value any) ([]any, error) {
-	text, ok := value.(string)
-	if ok {
-		if err := json.Unmarshal([]byte(text), &value); err != nil {
+	if insert {
+		if err := column.addUniqueValue(interfaceVal); err != nil {
 			return nil, err
 		}
 	}
 
-	interfaceArr, ok := value.([]any)
-	if !ok {
-		return nil, errors.New("failed to convert to array")
+	return interfaceVal, nil
+}
+
+// records the parsed value of unique columns, keys match the foreign key lookups across all the files of table
+func (column *Column) addUniqueValue(value any) error {
+	if !column.Unique {
+		return nil
+	}
+
+	key := templateValue(value, column.DataType)
+	if column.values[key] {
+		return errors.New("unique constraint not satisfied")
+	}
+	column.values[key] = true
+
+	return nil
+}
+
+// receives string or []any array and checks its dimensions & the array min & max length constraints
+// length constraints apply to the first dimension
+// also typecastes the array value into an array interface
+func (column *Column) validateValArrLen(value any) ([]any, error) {
+	interfaceArr, err := parseArray(value, column.DataType)
+	if err != nil {
+		return nil, err
 	}
 
 	if column.minArrLen != 0 {
-		res, ok := compareTypeValues(int64(len(interfaceArr)), column.minArrLen, "integer")
+		res, ok := compareTypeValues(int64(len(interfaceArr)), column.minArrLen, "bigint")
 		if !ok || res == -1 {
 			errorMessage := fmt.Sprintf("need at least %v elements in array", column.minArrLen)
 			return nil, errors.New(errorMessage)
@@ -363,7 +490,7 @@ func (column *Column) validateValArrLen(value any) ([]any, error) {
 	}
 
 	if column.maxArrLen != 0 {
-		res, ok := compareTypeValues(int64(len(interfaceArr)), column.maxArrLen, "integer")
+		res, ok := compareTypeValues(int64(len(interfaceArr)), column.maxArrLen, "bigint")
 		if !ok || res == 1 {
 			errorMessage := fmt.Sprintf("need at most %v elements in array", column.maxArrLen)
 			return nil, errors.New(errorMessage)
@@ -374,19 +501,33 @@ func (column *Column) validateValArrLen(value any) ([]any, error) {
 }
 
 // checks if the provided value (non-array) satisfies the min, max constraints
+// bounds are inclusive unless MinExclusive/MaxExclusive is set, text is bounded by its length
 func (column *Column) validateValueByMinMax(value any) error {
-	datatype := strings.TrimSuffix(column.DataType, "[]")
+	datatype := elementType(column.DataType)
+
+	if value == nil || (column.minIndividual == nil && column.maxIndividual == nil) {
+		return nil
+	}
+
+	if datatype == "text" {
+		str, ok := value.(string)
+		if !ok {
+			return errors.New("failed to typecast to text")
+		}
+		value = int64(utf8.RuneCountInString(str))
+		datatype = "bigint"
+	}
 
 	if column.minIndividual != nil {
 		res, ok := compareTypeValues(value, column.minIndividual, datatype)
-		if !ok || res == -1 {
+		if !ok || res == -1 || (res == 0 && column.MinExclusive) {
 			return errors.New("min constraint not satisfied")
 		}
 	}
 
 	if column.maxIndividual != nil {
 		res, ok := compareTypeValues(value, column.maxIndividual, datatype)
-		if !ok || res == 1 {
+		if !ok || res == 1 || (res == 0 && column.MaxExclusive) {
 			return errors.New("max constraint not satisfied")
 		}
 	}
@@ -435,7 +576,7 @@ func compareTypeValues(a, b any, datatype string) (int, bool) {
 			return 0, true
 		}
 
-	case "integer":
+	case "smallint", "integer", "bigint":
 		parsedA, ok := a.(int64)
 		if !ok {
 			return 0, false
@@ -497,6 +638,10 @@ func compareTypeValues(a, b any, datatype string) (int, bool) {
 		}
 	}
 
+	if baseType(datatype) == "numeric" {
+		return compareNumeric(a, b)
+	}
+
 	if datatype == "date" || datatype == "time" || datatype == "timestamptz" {
 		parsedA, ok := a.(time.Time)
 		if !ok {
@@ -523,19 +668,35 @@ func compareTypeValues(a, b any, datatype string) (int, bool) {
 
 // SQL Check Constraints templating for non-array variables
 func templateCheckConstraints(column Column, columnName string) string {
-	args := []string{} // Min, Max, Enum
+	args := []string{} // Min, Max, Enum, Patterns
+
+	target := fmt.Sprintf(`"%v"`, columnName)
+	boundType := column.DataType
+
+	if column.DataType == "text" {
+		target = fmt.Sprintf(`LENGTH("%v")`, columnName)
+		boundType = "bigint"
+	}
 
 	if column.minIndividual != nil {
-		formatted := templateValue(column.minIndividual, column.DataType)
-		args = append(args, fmt.Sprintf("\"%v\" > %v", columnName, formatted))
+		operator := ">="
+		if column.MinExclusive {
+			operator = ">"
+		}
+		formatted := templateValue(column.minIndividual, boundType)
+		args = append(args, fmt.Sprintf("%v %v %v", target, operator, formatted))
 	}
 
 	if column.maxIndividual != nil {
-		formatted := templateValue(column.maxIndividual, column.DataType)
-		args = append(args, fmt.Sprintf("\"%v\" < %v", columnName, formatted))
+		operator := "<="
+		if column.MaxExclusive {
+			operator = "<"
+		}
+		formatted := templateValue(column.maxIndividual, boundType)
+		args = append(args, fmt.Sprintf("%v %v %v", target, operator, formatted))
 	}
 
-	if len(column.Enums) > 0 {
+	if len(column.Enums) > 0 && column.EnumRef == "" {
 		var formattedValues []string
 		for _, val := range column.Enums {
 			formattedValue := templateValue(val, column.DataType)
@@ -544,6 +705,8 @@ func templateCheckConstraints(column Column, columnName string) string {
 		args = append(args, fmt.Sprintf(`"%s" IN (%s)`, columnName, strings.Join(formattedValues, ",")))
 	}
 
+	args = append(args, templatePatternConditions(column, fmt.Sprintf(`"%v"`, columnName))...)
+
 	if len(args) == 0 {
 		return ""
 	}
@@ -551,6 +714,36 @@ func templateCheckConstraints(column Column, columnName string) string {
 	return fmt.Sprintf(" CHECK ( %v )", strings.Join(args, " AND "))
 }
 
+// returns the label shown for a table or column, its name if the label is empty
+func displayLabel(label, name string) string {
+	if label == "" {
+		return name
+	}
+	return label
+}
+
+// returns the quoted SQL comment of a table or column, empty if the label is its name & there's no description
+// e.g. 'Student Father: name of the father'
+func templateComment(name, label, description string) string {
+	text := ""
+	if label != "" && label != name {
+		text = label
+	}
+
+	if description != "" {
+		if text != "" {
+			text += ": "
+		}
+		text += description
+	}
+
+	if text == "" {
+		return ""
+	}
+
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
 // get corresponding SQL value for all datatypes including array ones
 func templateValue(value any, datatype string) string {
 	if value == nil {
@@ -559,7 +752,7 @@ func templateValue(value any, datatype string) string {
 		return "NULL"
 	}
 
-	if datatype == "integer" || datatype == "real" || datatype == "boolean" {
+	if isUnquotedType(datatype) {
 		return fmt.Sprintf("%v", value)
 	}
 
@@ -571,36 +764,41 @@ func templateValue(value any, datatype string) string {
 		return fmt.Sprintf("'%v'", parsed.Format(datetimeFormats[datatype]))
 	}
 
-	if datatype == "text" {
+	if datatype == "text" || datatype == "uuid" {
 		return fmt.Sprintf("'%v'", value)
 	}
 
+	// JSON strings may contain quotes
+	if datatype == "jsonb" {
+		return fmt.Sprintf("'%v'", strings.ReplaceAll(fmt.Sprintf("%v", value), "'", "''"))
+	}
+
 	arr, ok := value.([]any)
 	if !ok {
 		return ""
 	}
 
-	datatype = strings.TrimSuffix(datatype, "[]")
+	return templateArray(arr, elementType(datatype)) + "::" + datatype
+}
+
+// renders nested arrays as nested array constructors, e.g. array[array[1, 2], array[3, 4]]
+func templateArray(arr []any, datatype string) string {
 	values := []string{}
 
 	for _, item := range arr {
-		formatted := fmt.Sprintf("'%v'", item)
-
-		if datatype == "date" || datatype == "time" || datatype == "timestamptz" {
-			parsed, ok := item.(time.Time)
-			if !ok {
-				formatted = "NULL"
-			}
-			formatted = fmt.Sprintf("'%v'", parsed.Format(datetimeFormats[datatype]))
-		}
-		if datatype == "integer" || datatype == "real" || datatype == "boolean" {
-			formatted = fmt.Sprintf("%v", item)
+		if subArr, ok := item.([]any); ok {
+			values = append(values, templateArray(subArr, datatype))
+		} else {
+			values = append(values, templateValue(item, datatype))
 		}
-
-		values = append(values, formatted)
 	}
 
-	return "array[" + strings.Join(values, ", ") + "]::" + datatype + "[]"
+	return "array[" + strings.Join(values, ", ") + "]"
+}
+
+// numbers & booleans are written without quotes in SQL
+func isUnquotedType(datatype string) bool {
+	return isIntegerType(datatype) || datatype == "real" || numericTypeRegex.MatchString(datatype) || datatype == "boolean"
 }
 
 // used in SQL trigger generation
@@ -609,17 +807,22 @@ func getArrayValidatorArgs(column Column) string {
 		column.maxArrLen == 0 &&
 		column.minIndividual == nil &&
 		column.maxIndividual == nil &&
-		len(column.Enums) == 0) {
+		(len(column.Enums) == 0 || column.EnumRef != "") &&
+		len(column.patterns) == 0) {
 		return ""
 	}
 
-	// NOT NULL, Min Arr Len, Max Arr Len, Min Individual, Max Individual, Enums
-	res := []string{"false", "NULL", "NULL", "NULL", "NULL", "NULL"}
+	// NOT NULL, Min Arr Len, Max Arr Len, Min Individual, Max Individual, Enums, Min Exclusive, Max Exclusive, Patterns (text only)
+	res := []string{"false", "NULL", "NULL", "NULL", "NULL", "NULL", "false", "false"}
 	if column.NotNull {
 		res[0] = "true"
 	}
 
-	datatype := strings.TrimSuffix(column.DataType, "[]")
+	datatype := elementType(column.DataType)
+	boundType := datatype
+	if datatype == "text" {
+		boundType = "bigint"
+	}
 
 	if column.minArrLen > 0 {
 		res[1] = templateValue(column.minArrLen, "integer")
@@ -630,17 +833,29 @@ func getArrayValidatorArgs(column Column) string {
 	}
 
 	if column.minIndividual != nil {
-		res[3] = templateValue(column.minIndividual, datatype)
+		res[3] = templateValue(column.minIndividual, boundType)
 	}
 
 	if column.maxIndividual != nil {
-		res[4] = templateValue(column.maxIndividual, datatype)
+		res[4] = templateValue(column.maxIndividual, boundType)
 	}
 
-	if len(column.Enums) > 0 {
+	if len(column.Enums) > 0 && column.EnumRef == "" {
 		res[5] = templateValue(column.Enums, datatype+"[]")
 	}
 
+	if column.MinExclusive {
+		res[6] = "true"
+	}
+
+	if column.MaxExclusive {
+		res[7] = "true"
+	}
+
+	if datatype == "text" {
+		res = append(res, templatePatternArray(column))
+	}
+
 	return strings.Join(res, ", ")
 }
 
@@ -698,57 +913,13 @@ func writeFile(filePath string, buffers ...*bytes.Buffer) error {
 	return nil
 }
 
-func hashText(val any, datatype string) (any, error) {
-	if datatype == "text" {
-		str, ok := val.(string)
-
-		if !ok {
-			return nil, errors.New("failed to typecast to text")
-		}
-
-		hashedVal, err := hashPassword(str)
-		if err != nil {
-			return nil, fmt.Errorf("error while hashing %s: %v", str, err)
-		}
-
-		return hashedVal, nil
-	}
-
-	if datatype == "text[]" {
-		arr, ok := val.([]any)
-		if !ok {
-			return nil, errors.New("failed to typecast to text[]")
-		}
-
-		for idx, item := range arr {
-			str, ok := item.(string)
-
-			if !ok {
-				return nil, fmt.Errorf("failed to typecast item no. %d to text", (idx + 1))
-			}
-
-			hashedVal, err := hashPassword(str)
-			if err != nil {
-				return nil, fmt.Errorf("error while hashing %s: %v", str, err)
-			}
-
-			arr[idx] = hashedVal
-		}
-
-		return arr, nil
-	}
-
-	return nil, errors.New("invalid datatype")
-}
-
-func hashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-
-	if err != nil {
-		return "", err
-	}
-
-	return string(hashedPassword), nil
+// wraps the provided buffers inside a single BEGIN/COMMIT block
+func wrapTransaction(buffers ...*bytes.Buffer) []*bytes.Buffer {
+	wrapped := make([]*bytes.Buffer, 0, len(buffers)+2)
+	wrapped = append(wrapped, bytes.NewBufferString("BEGIN;\n\n"))
+	wrapped = append(wrapped, buffers...)
+	wrapped = append(wrapped, bytes.NewBufferString("\nCOMMIT;\n"))
+	return wrapped
 }
 
 func writeJsonFile(filepath string, data any) error {
@@ -85,6 +85,66 @@ func Test_validateValueByType(t *testing.T) {
 			convertedVal: 1.6,
 			success:      true,
 		},
+		{
+			name:         "integer overflow",
+			args:         args{value: 5000000000, datatype: "integer"},
+			convertedVal: nil,
+			success:      false,
+		},
+		{
+			name:         "bigint",
+			args:         args{value: 5000000000, datatype: "bigint"},
+			convertedVal: int64(5000000000),
+			success:      true,
+		},
+		{
+			name:         "smallint overflow",
+			args:         args{value: 40000, datatype: "smallint"},
+			convertedVal: nil,
+			success:      false,
+		},
+		{
+			name:         "numeric rounded to scale",
+			args:         args{value: "-12.345", datatype: "numeric(5,2)"},
+			convertedVal: "-12.35",
+			success:      true,
+		},
+		{
+			name:         "numeric overflow",
+			args:         args{value: "1234.5", datatype: "numeric(5,2)"},
+			convertedVal: nil,
+			success:      false,
+		},
+		{
+			name:         "unconstrained numeric",
+			args:         args{value: "1.5e-3", datatype: "numeric"},
+			convertedVal: "0.0015",
+			success:      true,
+		},
+		{
+			name:         "uuid",
+			args:         args{value: "7C9E6679-7425-40DE-944B-E07FC1F90AE7", datatype: "uuid"},
+			convertedVal: "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+			success:      true,
+		},
+		{
+			name:         "invalid uuid",
+			args:         args{value: "7c9e6679", datatype: "uuid"},
+			convertedVal: nil,
+			success:      false,
+		},
+		{
+			name:         "jsonb",
+			args:         args{value: `{ "b": 1, "a": [true] }`, datatype: "jsonb"},
+			convertedVal: `{"a":[true],"b":1}`,
+			success:      true,
+		},
+		{
+			name:         "jsonb from schema",
+			args:         args{value: map[string]any{"k": "v"}, datatype: "jsonb"},
+			convertedVal: `{"k":"v"}`,
+			success:      true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -116,7 +176,7 @@ func TestColumn_validateDefaultValue(t *testing.T) {
 		minArrLen     int64
 		maxArrLen     int64
 		values        map[string]bool
-		lookup        map[string]int
+		lookup        map[string][]int
 	}
 	tests := []struct {
 		name    string
@@ -198,7 +258,7 @@ func TestColumn_validateValArrLen(t *testing.T) {
 		minArrLen     int64
 		maxArrLen     int64
 		values        map[string]bool
-		lookup        map[string]int
+		lookup        map[string][]int
 	}
 	type args struct {
 		value any
@@ -212,39 +272,81 @@ func TestColumn_validateValArrLen(t *testing.T) {
 	}{
 		{
 			name:    "valid integer arr",
-			fields:  fields{minArrLen: 2, maxArrLen: 5},
+			fields:  fields{DataType: "integer[]", minArrLen: 2, maxArrLen: 5},
 			args:    args{value: []any{int64(1), int64(2), int64(3)}},
 			want:    []any{int64(1), int64(2), int64(3)},
 			wantErr: false,
 		},
 		{
 			name:    "invalid string arr",
-			fields:  fields{minArrLen: 2, maxArrLen: 5},
+			fields:  fields{DataType: "text[]", minArrLen: 2, maxArrLen: 5},
 			args:    args{value: []any{"text"}},
 			want:    nil,
 			wantErr: true,
 		},
 		{
 			name:    "valid float string",
-			fields:  fields{minArrLen: 2, maxArrLen: 5},
+			fields:  fields{DataType: "real[]", minArrLen: 2, maxArrLen: 5},
 			args:    args{value: "[1.2, 2.5, 3.4]"},
 			want:    []any{1.2, 2.5, 3.4},
 			wantErr: false,
 		},
 		{
 			name:    "invalid string",
-			fields:  fields{minArrLen: 2, maxArrLen: 5},
+			fields:  fields{DataType: "text[]", minArrLen: 2, maxArrLen: 5},
 			args:    args{value: "not an array"},
 			want:    nil,
 			wantErr: true,
 		},
 		{
 			name:    "invalid string array",
-			fields:  fields{minArrLen: 2, maxArrLen: 5},
+			fields:  fields{DataType: "integer[]", minArrLen: 2, maxArrLen: 5},
 			args:    args{value: "[1]"},
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name:    "valid 2d array",
+			fields:  fields{DataType: "integer[][]", minArrLen: 2},
+			args:    args{value: "[[1, 2], [3, 4]]"},
+			want:    []any{[]any{1.0, 2.0}, []any{3.0, 4.0}},
+			wantErr: false,
+		},
+		{
+			name:    "first dimension length",
+			fields:  fields{DataType: "integer[][]", minArrLen: 2},
+			args:    args{value: "[[1, 2, 3]]"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "ragged array",
+			fields:  fields{DataType: "integer[][]"},
+			args:    args{value: "[[1, 2], [3]]"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "empty sub-array",
+			fields:  fields{DataType: "integer[][]"},
+			args:    args{value: "[[], []]"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "dimensions mismatch",
+			fields:  fields{DataType: "integer[][]"},
+			args:    args{value: "[1, 2]"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "empty array",
+			fields:  fields{DataType: "integer[][]"},
+			args:    args{value: "[]"},
+			want:    []any{},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -373,7 +475,7 @@ func TestColumn_validateEnums(t *testing.T) {
 		minArrLen     int64
 		maxArrLen     int64
 		values        map[string]bool
-		lookup        map[string]int
+		lookup        map[string][]int
 	}
 	tests := []struct {
 		name    string
@@ -436,6 +538,8 @@ func TestColumn_validateValueByConstraints(t *testing.T) {
 		Unique        bool
 		Min           string
 		Max           string
+		MinExclusive  bool
+		MaxExclusive  bool
 		Enums         []interface{}
 		Default       interface{}
 		ForeignTable  string
@@ -445,7 +549,7 @@ func TestColumn_validateValueByConstraints(t *testing.T) {
 		minArrLen     int64
 		maxArrLen     int64
 		values        map[string]bool
-		lookup        map[string]int
+		lookup        map[string][]int
 	}
 	type args struct {
 		value  any
@@ -497,6 +601,34 @@ func TestColumn_validateValueByConstraints(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name:    "inclusive int min",
+			fields:  fields{DataType: "integer", minIndividual: int64(5)},
+			args:    args{value: "5", insert: false},
+			want:    int64(5),
+			wantErr: false,
+		},
+		{
+			name:    "exclusive int min",
+			fields:  fields{DataType: "integer", minIndividual: int64(5), MinExclusive: true},
+			args:    args{value: "5", insert: false},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "exclusive real arr max",
+			fields:  fields{DataType: "real[]", maxIndividual: 3.3, MaxExclusive: true},
+			args:    args{value: "[1.2, 2.5, 3.3]", insert: false},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "exclusive text length max",
+			fields:  fields{DataType: "text", maxIndividual: int64(3), MaxExclusive: true},
+			args:    args{value: "Ram", insert: false},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -507,6 +639,8 @@ func TestColumn_validateValueByConstraints(t *testing.T) {
 				Unique:        tt.fields.Unique,
 				Min:           tt.fields.Min,
 				Max:           tt.fields.Max,
+				MinExclusive:  tt.fields.MinExclusive,
+				MaxExclusive:  tt.fields.MaxExclusive,
 				Enums:         tt.fields.Enums,
 				Default:       tt.fields.Default,
 				ForeignTable:  tt.fields.ForeignTable,
@@ -529,3 +663,49 @@ func TestColumn_validateValueByConstraints(t *testing.T) {
 		})
 	}
 }
+
+func Test_templateValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		datatype string
+		want     string
+	}{
+		{name: "numeric", value: "12.50", datatype: "numeric(10,2)", want: "12.50"},
+		{name: "numeric arr", value: []any{"1.25", "2.00"}, datatype: "numeric(10,2)[]", want: "array[1.25, 2.00]::numeric(10,2)[]"},
+		{name: "bigint", value: int64(5000000000), datatype: "bigint", want: "5000000000"},
+		{name: "uuid", value: "7c9e6679-7425-40de-944b-e07fc1f90ae7", datatype: "uuid", want: "'7c9e6679-7425-40de-944b-e07fc1f90ae7'"},
+		{name: "2d arr", value: []any{[]any{int64(1), int64(2)}, []any{int64(3), nil}}, datatype: "integer[][]", want: "array[array[1, 2], array[3, NULL]]::integer[][]"},
+		{name: "3d text arr", value: []any{[]any{[]any{"a"}}}, datatype: "text[][][]", want: "array[array[array['a']]]::text[][][]"},
+		{name: "jsonb with quote", value: `{"a":"it's"}`, datatype: "jsonb", want: `'{"a":"it''s"}'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := templateValue(tt.value, tt.datatype); got != tt.want {
+				t.Errorf("templateValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_templateComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		label       string
+		description string
+		want        string
+	}{
+		{name: "student_father", label: "Student Father", want: "'Student Father'"},
+		{name: "id", label: "id", want: ""},
+		{name: "id", want: ""},
+		{name: "dob", label: "dob", description: "student's date of birth", want: "'student''s date of birth'"},
+		{name: "student_father", label: "Student Father", description: "legal guardian", want: "'Student Father: legal guardian'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := templateComment(tt.name, tt.label, tt.description); got != tt.want {
+				t.Errorf("templateComment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
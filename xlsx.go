@@ -0,0 +1,332 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minimal reader of xlsx workbooks, cells are read as displayed text except numbers which keep their value
+// dates are stored as serial days since 1899-12-30 & identified by their number format
+
+type xlsxSheet struct {
+	Name string `xml:"name,attr"`
+	ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	path string // path of sheet XML in the archive
+}
+
+type xlsxWorkbook struct {
+	Sheets []xlsxSheet `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Style  int    `xml:"s,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// built-in number formats of dates & times
+var xlsxBuiltinDatetimeFormats = map[int]string{
+	14: "date", 15: "date", 16: "date", 17: "date", 22: "timestamptz",
+	18: "time", 19: "time", 20: "time", 21: "time", 45: "time", 46: "time", 47: "time",
+}
+
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func readXMLFile(archive *zip.Reader, name string, ptr any) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return xml.NewDecoder(file).Decode(ptr)
+}
+
+// returns the sheets of workbook in order along with their paths
+func readXLSXSheets(archive *zip.Reader) ([]xlsxSheet, error) {
+	var workbook xlsxWorkbook
+	if err := readXMLFile(archive, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+
+	var relationships xlsxRelationships
+	if err := readXMLFile(archive, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string, len(relationships.Relationships))
+	for _, relationship := range relationships.Relationships {
+		target := relationship.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[relationship.ID] = target
+	}
+
+	for idx, sheet := range workbook.Sheets {
+		target, ok := targets[sheet.ID]
+		if !ok {
+			return nil, fmt.Errorf("sheet %s not found", sheet.Name)
+		}
+		workbook.Sheets[idx].path = target
+	}
+
+	return workbook.Sheets, nil
+}
+
+// reads the rows of a sheet along with their row no. in the sheet, which has gaps where the sheet skips empty rows
+// rows are padded to the length of the first row & trailing empty cells are dropped, rows having values beyond the first row are errors
+func readXLSXRows(archive *zip.Reader, sheetName string) ([][]string, []int, error) {
+	sheets, err := readXLSXSheets(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sheetPath := ""
+	for _, sheet := range sheets {
+		if sheet.Name == sheetName {
+			sheetPath = sheet.path
+		}
+	}
+	if sheetPath == "" {
+		return nil, nil, fmt.Errorf("sheet %s not found", sheetName)
+	}
+
+	// workbooks without strings or styles don't have these parts
+	var sharedStrings xlsxSharedStrings
+	if _, err := fs.Stat(archive, "xl/sharedStrings.xml"); err == nil {
+		if err := readXMLFile(archive, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var styles xlsxStyles
+	if _, err := fs.Stat(archive, "xl/styles.xml"); err == nil {
+		if err := readXMLFile(archive, "xl/styles.xml", &styles); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	strs := make([]string, len(sharedStrings.Items))
+	for idx, item := range sharedStrings.Items {
+		strs[idx] = item.Text
+		for _, run := range item.Runs {
+			strs[idx] += run.Text
+		}
+	}
+
+	customFormats := make(map[int]string, len(styles.NumFmts))
+	for _, numFmt := range styles.NumFmts {
+		customFormats[numFmt.ID] = numFmt.Code
+	}
+
+	// datetime type of each cell style
+	styleTypes := make([]string, len(styles.CellXfs))
+	for idx, xf := range styles.CellXfs {
+		if datatype, ok := xlsxBuiltinDatetimeFormats[xf.NumFmtID]; ok {
+			styleTypes[idx] = datatype
+		} else if code, ok := customFormats[xf.NumFmtID]; ok {
+			styleTypes[idx] = xlsxFormatType(code)
+		}
+	}
+
+	var worksheet xlsxWorksheet
+	if err := readXMLFile(archive, sheetPath, &worksheet); err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([][]string, 0, len(worksheet.Rows))
+	numbers := make([]int, 0, len(worksheet.Rows))
+	for _, xmlRow := range worksheet.Rows {
+		row := []string{}
+
+		// r is optional, rows without it follow the previous one
+		rowNumber := xmlRow.Number
+		if rowNumber == 0 {
+			rowNumber = 1
+			if len(numbers) > 0 {
+				rowNumber = numbers[len(numbers)-1] + 1
+			}
+		}
+
+		for idx, cell := range xmlRow.Cells {
+			column := idx
+			if cell.Ref != "" {
+				if column, err = xlsxColumnIndex(cell.Ref); err != nil {
+					return nil, nil, err
+				}
+			}
+
+			for len(row) <= column {
+				row = append(row, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				strIdx, err := strconv.Atoi(value)
+				if err != nil || strIdx < 0 || strIdx >= len(strs) {
+					return nil, nil, fmt.Errorf("invalid shared string in cell %s", cell.Ref)
+				}
+				value = strs[strIdx]
+			case "inlineStr":
+				value = cell.Inline.Text
+			case "b":
+				value = strconv.FormatBool(value == "1")
+			case "e":
+				value = ""
+			case "", "n":
+				if cell.Style >= 0 && cell.Style < len(styleTypes) && styleTypes[cell.Style] != "" && value != "" {
+					value = xlsxDatetime(value, styleTypes[cell.Style])
+				}
+			}
+
+			row[column] = value
+		}
+
+		// cells without values, e.g. formatted ones, are dropped beyond the headers & from the end of the header row
+		headersCount := 0
+		if len(rows) > 0 {
+			headersCount = len(rows[0])
+		}
+		for len(row) > headersCount && row[len(row)-1] == "" {
+			row = row[:len(row)-1]
+		}
+
+		if len(rows) > 0 {
+			for len(row) < headersCount {
+				row = append(row, "")
+			}
+
+			if len(row) > headersCount {
+				return nil, nil, fmt.Errorf("sheet %s row %d: wrong number of fields, %d while the first row has %d", sheetName, rowNumber, len(row), headersCount)
+			}
+		}
+
+		rows = append(rows, row)
+		numbers = append(numbers, rowNumber)
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("sheet %s is empty", sheetName)
+	}
+
+	return rows, numbers, nil
+}
+
+// returns the zero-based column index of a cell reference, e.g. AB12 -> 27
+func xlsxColumnIndex(ref string) (int, error) {
+	index := 0
+	for _, char := range ref {
+		if char >= 'A' && char <= 'Z' {
+			index = index*26 + int(char-'A') + 1
+		} else {
+			break
+		}
+	}
+
+	if index == 0 {
+		return 0, fmt.Errorf("invalid cell reference %s", ref)
+	}
+
+	return index - 1, nil
+}
+
+// returns date, time, timestamptz or empty string for the number format code
+func xlsxFormatType(code string) string {
+	// quoted literals & bracketed sections like colors aren't part of the format
+	var builder strings.Builder
+	skipUntil := rune(0)
+	for _, char := range strings.ToLower(code) {
+		switch {
+		case skipUntil != 0:
+			if char == skipUntil {
+				skipUntil = 0
+			}
+		case char == '"':
+			skipUntil = '"'
+		case char == '[':
+			skipUntil = ']'
+		default:
+			builder.WriteRune(char)
+		}
+	}
+	code = builder.String()
+
+	hasDate := strings.ContainsAny(code, "yd")
+	hasTime := strings.ContainsAny(code, "hs")
+
+	switch {
+	case hasDate && hasTime:
+		return "timestamptz"
+	case hasDate:
+		return "date"
+	case hasTime:
+		return "time"
+	}
+	return ""
+}
+
+// converts a serial date into the text of datatype, invalid serials are returned as is
+func xlsxDatetime(value, datatype string) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 0 {
+		return value
+	}
+
+	days, fraction := math.Modf(serial)
+	seconds := math.Round(fraction * 24 * 60 * 60)
+	parsed := xlsxEpoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+
+	switch datatype {
+	case "date":
+		return parsed.Format(time.DateOnly)
+	case "time":
+		return parsed.Format(time.TimeOnly)
+	}
+	return parsed.Format(time.DateTime)
+}